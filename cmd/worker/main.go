@@ -5,7 +5,6 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"net/http"
 	_ "net/http/pprof"
 	"net/url"
 	"os"
@@ -20,6 +19,9 @@ import (
 	"github.com/mergestat/mergestat/internal/helper"
 	"github.com/mergestat/mergestat/internal/jobs/repo"
 	"github.com/mergestat/mergestat/internal/jobs/sync/podman"
+	"github.com/mergestat/mergestat/internal/leader"
+	"github.com/mergestat/mergestat/internal/metrics"
+	"github.com/mergestat/mergestat/internal/ops"
 	"github.com/mergestat/mergestat/internal/syncer"
 	"github.com/mergestat/mergestat/internal/timeout"
 	"github.com/mergestat/mergestat/queries"
@@ -38,10 +40,8 @@ import (
 	"github.com/mergestat/mergestat-lite/extensions"
 	"github.com/mergestat/mergestat-lite/extensions/options"
 	"github.com/mergestat/mergestat-lite/extensions/services"
-	"github.com/mergestat/mergestat-lite/pkg/locator"
 	_ "github.com/mergestat/mergestat-lite/pkg/sqlite"
 	"github.com/mergestat/mergestat/internal/scheduler"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
 	"github.com/shurcooL/githubv4"
 	"go.riyazali.net/sqlite"
@@ -52,6 +52,7 @@ var (
 	postgresConnection = os.Getenv("POSTGRES_CONNECTION")
 	// baseCloneDir       = os.Getenv("BASE_CLONE_DIR")
 	concurrencyEnv = os.Getenv("CONCURRENCY")
+	opsListenAddr  = os.Getenv("OPS_LISTEN_ADDR")
 )
 
 func repoLocator() services.RepoLocator {
@@ -105,6 +106,10 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	if opsListenAddr == "" {
+		opsListenAddr = ":8080"
+	}
+
 	var err error
 	concurrency := 1
 	if concurrencyEnv != "" {
@@ -159,6 +164,9 @@ func main() {
 		}
 	}
 
+	// the version the database was migrated to, required by the readiness check of the ops server
+	migrationVersion, _, _ := m.Version()
+
 	srcErr, dbErr := m.Close()
 	if srcErr != nil {
 		logger.Err(srcErr).Msgf("could not close migrations with source error: %v", srcErr)
@@ -181,6 +189,8 @@ func main() {
 			delayDur = untilResetDur
 		}
 
+		metrics.GitHubRateLimitRemaining.WithLabelValues("graphql").Set(float64(rlr.Remaining))
+
 		if err := helper.WaitForImports(ctx, &l, queries.NewQuerier(db.New(pool))); err != nil {
 			l.Err(err).Msgf("error waiting for imports: %v", err)
 		}
@@ -240,7 +250,9 @@ func main() {
 	sqlite.Register(
 		extensions.RegisterFn(
 			options.WithExtraFunctions(),
-			options.WithRepoLocator(locator.CachedLocator(repoLocator())),
+			// clones are persisted and updated in place by the syncer's clone cache,
+			// so repos must not be cached here or queries could read stale objects
+			options.WithRepoLocator(repoLocator()),
 			options.WithGitHub(),
 			// options.WithContextValue("githubToken", os.Getenv("GITHUB_TOKEN")),
 			options.WithContextValue("githubPerPage", os.Getenv("GITHUB_PER_PAGE")),
@@ -271,40 +283,46 @@ func main() {
 	_ = worker.Register("repos/auto-import", repo.AutoImport(pool))
 	_ = worker.Register("container/sync", podman.ContainerSync(u.String(), &logger, db.New(pool)))
 
+	// repo syncs are executed by the sqlq worker as well, for the sync types this worker can run
+	var syncWorker = syncer.New(pool, upstream, embedded, &logger, concurrency)
+	if err = syncWorker.RegisterHandlers(worker); err != nil {
+		logger.Fatal().Err(err).Msg("failed to register sync handlers")
+	}
+
 	// TODO all of the following "params" should be configurable
 	// either via the database/app or possibly with env vars
 	schedulerInterval := 1
-	syncerInterval := 3
 
 	if schedulerIntervalStr := os.Getenv("SCHEDULER_INTERVAL_MINUTES"); len(schedulerIntervalStr) != 0 {
 		if schedulerInterval, err = strconv.Atoi(schedulerIntervalStr); err != nil {
 			logger.Err(err).Msgf("Incorrect value for SCHEDULER_INTERVAL_MINUTES")
 		}
 	}
-	if syncerIntervalStr := os.Getenv("SYNCER_INTERVAL_SECONDS"); len(syncerIntervalStr) != 0 {
-		if syncerInterval, err = strconv.Atoi(syncerIntervalStr); err != nil {
-			logger.Err(err).Msgf("Incorrect value for SYNCER_INTERVAL_SECONDS")
-		}
-	}
-	go scheduler.New(&logger, pool).Start(ctx, time.Duration(schedulerInterval)*time.Minute)
-	go timeout.New(&logger, pool).Start(ctx, time.Minute)
-	go syncer.New(pool, embedded, &logger, concurrency, time.Duration(syncerInterval)*time.Second).Start(ctx)
-
-	// run a basic cron every minute to schedule a repos/auto-import job
-	// these jobs are idempotent, and so, multiple instances can run at same time without conflict
-	go cron.AutoImport(ctx, 15*time.Second, upstream)
-
-	// run container sync scheduler every minute
-	go cron.ContainerSync(ctx, 1*time.Minute, upstream)
-
-	if os.Getenv("DEBUG") != "" {
-		go func() {
-			http.Handle("/metrics", promhttp.Handler())
-			if err := http.ListenAndServe(":8080", nil); err != nil {
-				logger.Err(err).Msgf("could not start HTTP handler")
-			}
-		}()
+	var syncWorkerDone = make(chan struct{})
+	go func() { syncWorker.Start(ctx); close(syncWorkerDone) }()
+
+	// the periodic routines only run in the replica elected as leader, and fail over to another one if it dies
+	go leader.Run(ctx, &logger, pool, "mergestat/cron",
+		func(ctx context.Context) {
+			scheduler.New(&logger, pool).Start(ctx, time.Duration(schedulerInterval)*time.Minute)
+		},
+		func(ctx context.Context) { timeout.New(&logger, pool).Start(ctx, time.Minute) },
+
+		// run a basic cron every 15 seconds to schedule a repos/auto-import job
+		func(ctx context.Context) { cron.AutoImport(ctx, 15*time.Second, upstream) },
+
+		// run container sync scheduler every minute
+		func(ctx context.Context) { cron.ContainerSync(ctx, 1*time.Minute, upstream) },
+
+		// run a basic cron every hour to delete the file contents no longer referenced by any repo
+		func(ctx context.Context) { cron.OrphanedBlobs(ctx, time.Hour, pool) },
+	)
+
+	// serve health, readiness and metrics (and pprof, in debug mode) for orchestrators and monitoring
+	if err = metrics.RegisterQueueDepth(db.New(pool)); err != nil {
+		logger.Err(err).Msg("could not register queue depth metrics")
 	}
+	go ops.Serve(ctx, &logger, opsListenAddr, ops.New(&logger, pool, migrationVersion, os.Getenv("DEBUG") != ""))
 
 	// start the worker
 	if err = worker.Start(); err != nil {
@@ -317,4 +335,5 @@ func main() {
 	if err = worker.Shutdown(30 * time.Second); err != nil {
 		logger.Err(err).Msg("failed to terminate worker gracefully")
 	}
+	<-syncWorkerDone
 }
@@ -0,0 +1,42 @@
+package cron
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mergestat/mergestat/internal/db"
+	"github.com/rs/zerolog"
+)
+
+// orphanedBlobsBatch is the number of blobs deleted at once, to keep the locks held on git_blobs short
+const orphanedBlobsBatch = 10000
+
+// OrphanedBlobs provides a cron function that periodically deletes the blobs of git_blobs
+// that no file of git_files references anymore, e.g. after a repo is removed or its files change.
+func OrphanedBlobs(ctx context.Context, dur time.Duration, pool *pgxpool.Pool) {
+	var log = zerolog.Ctx(ctx)
+	var queries = db.New(pool)
+
+	var fn = func(ctx context.Context) (total int64, err error) {
+		for {
+			var n int64
+			if n, err = queries.DeleteOrphanedGitBlobs(ctx, orphanedBlobsBatch); err != nil {
+				return total, err
+			}
+
+			if total += n; n < orphanedBlobsBatch {
+				return total, nil
+			}
+		}
+	}
+
+	// reuse existing loop-select functionality in Basic()
+	Basic(ctx, dur, func() {
+		if n, err := fn(ctx); err != nil {
+			log.Err(err).Msg("failed to delete orphaned blobs")
+		} else if n > 0 {
+			log.Info().Msgf("deleted %d orphaned blob(s)", n)
+		}
+	})
+}
@@ -30,6 +30,20 @@ type GitBlame struct {
 	Path string
 	// timestamp when record was synced into the MergeStat database
 	MergestatSyncedAt time.Time
+	// ref the blamed file was synced from
+	Ref string
+}
+
+// contents of the git blobs of the files of the repos, shared by all the files with the same contents
+type GitBlob struct {
+	// hash of the git blob
+	Sha string
+	// contents of the blob, NULL if they were omitted for every file referencing it (see git_files.contents_omitted_reason)
+	Contents sql.NullString
+	// size of the blob in bytes
+	Size int64
+	// timestamp when record was synced into the MergeStat database
+	MergestatSyncedAt time.Time
 }
 
 type GitBranch struct {
@@ -76,6 +90,22 @@ type GitCommit struct {
 	Parents int32
 	// timestamp when record was synced into the MergeStat database
 	MergestatSyncedAt time.Time
+	// ref the commit was synced from (the first of the synced refs it is reachable from)
+	Ref sql.NullString
+	// hashes of the parents of the commit, in order (the first parent first)
+	ParentHashes []string
+	// hash of the tree of the commit
+	TreeHash sql.NullString
+	// type of the signature of the commit: gpg, ssh or x509, NULL if the commit is not signed
+	SignatureType sql.NullString
+	// ID of the key that signed the commit, as shown by git log --format=%GK: the long key ID for gpg, the SHA256 fingerprint of the public key for ssh, NULL if unknown
+	SignerKeyID sql.NullString
+	// type of the commit, lower cased (e.g. feat or fix), if its message follows the Conventional Commits specification
+	ConventionalType sql.NullString
+	// scope of the commit, if its message follows the Conventional Commits specification and has one
+	ConventionalScope sql.NullString
+	// boolean to determine if the commit introduces a breaking change (a ! after its type or a BREAKING CHANGE trailer), NULL if its message does not follow the Conventional Commits specification
+	IsBreakingChange sql.NullBool
 }
 
 // git commit stats of a repo
@@ -98,7 +128,29 @@ type GitCommitStat struct {
 	NewFileMode string
 }
 
-// git files (content and paths) of a repo
+// trailers of the messages of the commits of a repo, such as Co-authored-by or Signed-off-by
+type GitCommitTrailer struct {
+	// foreign key for public.repos.id
+	RepoID uuid.UUID
+	// hash of the commit
+	CommitHash string
+	// position of the trailer in the message of the commit, starting at 0
+	Position int32
+	// key of the trailer, lower cased (e.g. co-authored-by, signed-off-by, reviewed-by)
+	Key string
+	// value of the trailer
+	Value string
+	// name of the identity the value of the trailer refers to, if it is one (e.g. Jane Doe <jane@example.com>)
+	Name sql.NullString
+	// email of the identity the value of the trailer refers to, if it is one
+	Email sql.NullString
+	// timestamp when record was synced into the MergeStat database
+	MergestatSyncedAt time.Time
+}
+
+// @primaryKey repo_id,ref,path
+// @foreignKey (repo_id) references public.repos (id)
+// git files (content and paths) of a repo, the contents being those of the blob of the file
 type GitFile struct {
 	// foreign key for public.repos.id
 	RepoID uuid.UUID
@@ -110,6 +162,66 @@ type GitFile struct {
 	Contents sql.NullString
 	// timestamp when record was synced into the MergeStat database
 	MergestatSyncedAt time.Time
+	// ref the file was synced from
+	Ref string
+	// hash of the git blob of the file, foreign key for public.git_blobs.sha
+	BlobSha sql.NullString
+	// size of the file in bytes
+	Size sql.NullInt64
+	// language of the file, as detected by go-enry, NULL if unknown
+	Language sql.NullString
+	// boolean to determine if the file is binary, NULL if its contents were omitted for being too large
+	IsBinary sql.NullBool
+	// boolean to determine if the file is vendored, e.g. a third party dependency
+	IsVendored sql.NullBool
+	// boolean to determine if the file is generated, NULL if its contents were omitted for being too large
+	IsGenerated sql.NullBool
+	// boolean to determine if the file is documentation
+	IsDocumentation sql.NullBool
+	// number of lines of code of the file, NULL if it is binary or its contents were omitted for being too large
+	CodeLines sql.NullInt32
+	// number of comment lines of the file, NULL if it is binary or its contents were omitted for being too large
+	CommentLines sql.NullInt32
+	// number of blank lines of the file, NULL if it is binary or its contents were omitted for being too large
+	BlankLines sql.NullInt32
+	// why the contents of the file were not stored, if they were not: invalid_utf8 (the file is not valid UTF-8 text) or too_large (the file is larger than the maxFileSize setting of the sync)
+	ContentsOmittedReason sql.NullString
+}
+
+// git files (paths and metadata) of a repo, their contents are in git_blobs (see the git_files view)
+type GitFileEntry struct {
+	// foreign key for public.repos.id
+	RepoID uuid.UUID
+	// path of the file
+	Path string
+	// boolean to determine if the file is an executable
+	Executable bool
+	// timestamp when record was synced into the MergeStat database
+	MergestatSyncedAt time.Time
+	// ref the file was synced from
+	Ref string
+	// hash of the git blob of the file, foreign key for public.git_blobs.sha
+	BlobSha sql.NullString
+	// size of the file in bytes
+	Size sql.NullInt64
+	// language of the file, as detected by go-enry, NULL if unknown
+	Language sql.NullString
+	// boolean to determine if the file is binary, NULL if its contents were omitted for being too large
+	IsBinary sql.NullBool
+	// boolean to determine if the file is vendored, e.g. a third party dependency
+	IsVendored sql.NullBool
+	// boolean to determine if the file is generated, NULL if its contents were omitted for being too large
+	IsGenerated sql.NullBool
+	// boolean to determine if the file is documentation
+	IsDocumentation sql.NullBool
+	// number of lines of code of the file, NULL if it is binary or its contents were omitted for being too large
+	CodeLines sql.NullInt32
+	// number of comment lines of the file, NULL if it is binary or its contents were omitted for being too large
+	CommentLines sql.NullInt32
+	// number of blank lines of the file, NULL if it is binary or its contents were omitted for being too large
+	BlankLines sql.NullInt32
+	// why the contents of the file were not stored, if they were not: invalid_utf8 (the file is not valid UTF-8 text) or too_large (the file is larger than the maxFileSize setting of the sync)
+	ContentsOmittedReason sql.NullString
 }
 
 // git refs of a repo
@@ -746,6 +858,36 @@ type MergestatRepoSync struct {
 	ScheduleEnabled              bool
 	Priority                     int32
 	LastCompletedRepoSyncQueueID sql.NullInt64
+	// cron expression of the times the sync is due to run, overriding the default of its sync type if not NULL. Syncs depending on other syncs (see mergestat.repo_sync_type_dependencies) run once due and their prerequisites are done
+	Schedule sql.NullString
+	// minimum time between two runs of the sync, overriding the default of its sync type if not NULL
+	MinInterval pgtype.Interval
+}
+
+// Last synced commit per ref of a repo sync, used by git syncs to only process what changed since the previous run
+type MergestatRepoSyncCheckpoint struct {
+	// foreign key for mergestat.repo_syncs.id
+	RepoSyncID uuid.UUID
+	// fully qualified name of the ref that was synced
+	Ref string
+	// hash of the commit the ref pointed to when it was last synced
+	CommitHash string
+	// timestamp of when the checkpoint was last updated
+	UpdatedAt time.Time
+	// hash of the settings of the sync that affect its results, when the checkpoint was last updated. A sync processes everything again if they changed since then
+	SettingsHash sql.NullString
+	// paths of the files that could not be processed by the sync when the checkpoint was last updated, which the next sync processes again even if they did not change
+	FailedPaths []string
+}
+
+// Maximum number of syncs of a type group running at once, across all workers, for the repos of a provider. Without a limit, the concurrent syncs of the type group apply
+type MergestatRepoSyncConcurrencyLimit struct {
+	// provider of the repos the limit applies to
+	Provider uuid.UUID
+	// type group of the syncs the limit applies to
+	TypeGroup string
+	// maximum number of syncs running at once
+	ConcurrentSyncs int32
 }
 
 type MergestatRepoSyncLog struct {
@@ -771,6 +913,16 @@ type MergestatRepoSyncQueue struct {
 	LastKeepAlive sql.NullTime
 	Priority      int32
 	TypeGroup     string
+	// number of the current attempt of the job, starting at 1
+	Attempt int32
+	// timestamp before which the job is not dequeued, e.g. to spread scheduled syncs with the jitter of their sync type
+	RunAfter sql.NullTime
+	// timestamp of when the cancellation of the job was requested, the job is canceled by its worker if it's running
+	CancelRequestedAt sql.NullTime
+	// ID of the worker (see mergestat.workers) that dequeued the job last
+	WorkerID uuid.NullUUID
+	// ID of the sqlq job executing the job (see mergestat.enqueue_repo_sync_job)
+	SqlqJobID uuid.NullUUID
 }
 
 type MergestatRepoSyncQueueStatusType struct {
@@ -784,6 +936,30 @@ type MergestatRepoSyncType struct {
 	ShortName   string
 	Priority    int32
 	TypeGroup   string
+	// JSON schema the settings of syncs of this type (mergestat.repo_syncs.settings) are validated against before they are executed, NULL if the type has no settings
+	SettingsSchema pgtype.JSONB
+	// maximum number of times a sync of this type is attempted before it is marked as FAILED, 1 disables retries
+	RetryMaxAttempts int32
+	// delay before the first retry of a failed sync, doubled on every following attempt
+	RetryBackoffBase pgtype.Interval
+	// upper bound of the delay between two attempts of a sync
+	RetryBackoffMax pgtype.Interval
+	// classes of errors that are retried: network (connection errors, timeouts), database (connection loss, serialization failures, deadlocks), github (server errors, rate limits)
+	RetryOn []string
+	// maximum time a sync of this type may run for before it's canceled by its worker and marked as FAILED, NULL if unlimited
+	MaxRuntime pgtype.Interval
+	// default cron expression (e.g. "0 3 * * *" or "@daily") of the times syncs of this type are due to run, NULL to run them on every scheduling cycle
+	Schedule sql.NullString
+	// default minimum time between two runs of a sync of this type, NULL if unlimited
+	MinInterval pgtype.Interval
+	// start of the daily time window (in window_timezone) syncs of this type may be scheduled in, NULL to schedule them at any time. The window wraps around midnight if it ends before it starts (e.g. 22:00 to 06:00)
+	WindowStart pgtype.Time
+	// end of the daily time window (in window_timezone) syncs of this type may be scheduled in, NULL to schedule them at any time
+	WindowEnd pgtype.Time
+	// time zone of window_start and window_end, e.g. America/New_York
+	WindowTimezone string
+	// maximum random delay applied to syncs of this type when they're scheduled, to spread them over time instead of running them all at once. NULL to run them right away
+	Jitter pgtype.Interval
 }
 
 type MergestatRepoSyncTypeGroup struct {
@@ -804,6 +980,14 @@ type MergestatRepoSyncTypeLabelAssociation struct {
 	RepoSyncType string
 }
 
+// Dependencies between sync types. On every scheduling cycle, a sync of a repo is enqueued only after the enabled syncs of the types it depends on for the same repo complete successfully
+type MergestatRepoSyncTypeDependency struct {
+	// the dependent sync type
+	SyncType string
+	// the sync type that must complete before syncs of the dependent type are enqueued
+	DependsOn string
+}
+
 // Table to save queries
 type MergestatSavedQuery struct {
 	ID uuid.UUID
@@ -876,6 +1060,37 @@ type MergestatVendorType struct {
 	Description sql.NullString
 }
 
+// Workers processing repo syncs, with the capabilities they advertise. A worker only dequeues jobs of the sync types it registered
+type MergestatWorker struct {
+	ID uuid.UUID
+	// hostname of the machine or container the worker runs on
+	Hostname string
+	// capabilities available to the worker, e.g. clone or executable:trivy
+	Capabilities []string
+	// sync types the worker can run: the ones it has a handler for, whose required capabilities are available, and that are allowed by its configuration
+	SyncTypes []string
+	// time the worker started at
+	StartedAt time.Time
+	// version of the worker binary
+	Version sql.NullString
+	// number of jobs the worker runs concurrently
+	Concurrency int32
+	// last time the worker reported it was alive. The running jobs of workers without a recent heartbeat are re-queued, and the workers removed after a while
+	LastHeartbeatAt time.Time
+}
+
+// Exec loops of the registered workers, with the job each of them is running, if any
+type MergestatWorkerExecLoop struct {
+	WorkerID        uuid.UUID
+	Hostname        string
+	LastHeartbeatAt time.Time
+	ExecLoop        interface{}
+	RepoSyncQueueID sql.NullInt64
+	SyncType        sql.NullString
+	RepoID          uuid.NullUUID
+	StartedAt       sql.NullTime
+}
+
 type OssfScorecardRepoCheckResult struct {
 	// foreign key for public.repos.id
 	RepoID uuid.UUID
@@ -6,39 +6,72 @@ package db
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
 )
 
 type Querier interface {
 	CheckRunningImps(ctx context.Context) (int64, error)
 	CleanOldJobs(ctx context.Context, dollar_1 int32) error
 	CleanOldRepoSyncQueue(ctx context.Context, dollar_1 int32) error
+	CountSyncJobsByStatus(ctx context.Context) ([]CountSyncJobsByStatusRow, error)
+	DeleteDeadWorkers(ctx context.Context) (int64, error)
 	DeleteGitHubRepoInfo(ctx context.Context, repoID uuid.UUID) error
+	// Deletes a batch of the blobs no file references anymore. Blobs locked by the syncs upserting them are skipped, so that they aren't deleted before the files referencing them are inserted.
+	DeleteOrphanedGitBlobs(ctx context.Context, lim int32) (int64, error)
 	DeleteRemovedRepos(ctx context.Context, arg DeleteRemovedReposParams) error
-	DequeueSyncJob(ctx context.Context) (DequeueSyncJobRow, error)
+	DeleteRepoSyncCheckpoints(ctx context.Context, repoSyncID uuid.UUID) error
+	DeleteWorker(ctx context.Context, id uuid.UUID) error
+	// Dequeues the given job when the sqlq job executing it (see mergestat.enqueue_repo_sync_job) is picked up by the worker.
+	// A job left running by a worker that stopped sending heartbeats is dequeued again, e.g. when sqlq retries it before
+	// RequeueOrphanedSyncJobs queues it again; a job still running on a live worker never is.
+	DequeueSyncJob(ctx context.Context, arg DequeueSyncJobParams) (DequeueSyncJobRow, error)
 	EnableContainerSync(ctx context.Context, arg EnableContainerSyncParams) error
 	// We use a CTE here to retrieve all the repo_sync_jobs that were previously enqueued, to make sure that we *do not* re-enqueue anything new until the previously enqueued jobs are *completed*.
 	// This allows us to make sure all repo syncs complete before we reschedule a new batch.
 	// We have now also added a concept of type groups which allows us to apply this same logic but by each group type which is where the PARTITION BY clause comes into play
-	EnqueueAllSyncs(ctx context.Context) error
+	// Syncs depending on other syncs of the same repo (see mergestat.repo_sync_type_dependencies) are only enqueued once their prerequisites completed successfully.
+	// Only the syncs due according to their schedule (see ListSyncSchedules) are enqueued, or all of them if due_sync_ids is NULL.
+	// Syncs are only enqueued in the daily time window of their sync type, if any, and delayed by a random fraction of its jitter to spread them over time.
+	EnqueueAllSyncs(ctx context.Context, dueSyncIds []uuid.UUID) error
+	// Creates a new sqlq job for the queued jobs whose sqlq job ended without running them (e.g. the worker that picked it up died before dequeuing the job), as sqlq doesn't retry it. The jobs no worker can run are left to FailUnrunnableSyncJobs.
+	EnqueueStrandedSyncJobs(ctx context.Context) (int64, error)
+	// Marks as FAILED the queued jobs no live worker can run (see mergestat.sync_type_runnable), e.g. when the workers able to run them were stopped after they were queued.
+	FailUnrunnableSyncJobs(ctx context.Context) ([]int64, error)
 	FetchContainerSync(ctx context.Context, id uuid.UUID) (FetchContainerSyncRow, error)
 	FetchGitHubToken(ctx context.Context, pgpSymDecrypt string) (string, error)
 	FetchImportJob(ctx context.Context, id uuid.UUID) (FetchImportJobRow, error)
 	GetRepoById(ctx context.Context, id uuid.UUID) (Repo, error)
 	GetRepoIDsFromRepoImport(ctx context.Context, arg GetRepoIDsFromRepoImportParams) ([]uuid.UUID, error)
 	GetRepoImportByID(ctx context.Context, id uuid.UUID) (MergestatRepoImport, error)
+	GetRepoSyncTypeSettingsSchema(ctx context.Context, type_ string) (pgtype.JSONB, error)
 	GetRepoUrlFromImport(ctx context.Context, importid uuid.UUID) ([]string, error)
+	GetSyncJobRetryPolicy(ctx context.Context, id int64) (GetSyncJobRetryPolicyRow, error)
 	InsertGitHubRepoInfo(ctx context.Context, arg InsertGitHubRepoInfoParams) error
 	InsertNewDefaultSync(ctx context.Context, arg InsertNewDefaultSyncParams) error
 	InsertSyncJobLog(ctx context.Context, arg InsertSyncJobLogParams) error
+	ListEnabledSyncTypes(ctx context.Context) ([]string, error)
 	ListRepoImportsDueForImport(ctx context.Context) ([]ListRepoImportsDueForImportRow, error)
+	ListRepoSyncCheckpoints(ctx context.Context, repoSyncID uuid.UUID) ([]MergestatRepoSyncCheckpoint, error)
+	// Lists the enabled syncs with their effective schedule (their own, else the default of their sync type) and the time they were last enqueued at.
+	ListSyncSchedules(ctx context.Context) ([]ListSyncSchedulesRow, error)
 	MarkRepoImportAsUpdated(ctx context.Context, id uuid.UUID) error
 	MarkSyncsAsTimedOut(ctx context.Context) ([]int64, error)
-	SetLatestKeepAliveForJob(ctx context.Context, id int64) error
+	RegisterWorker(ctx context.Context, arg RegisterWorkerParams) error
+	// Re-queues the jobs left running by workers that stopped sending heartbeats (or were removed), logging which worker ran them.
+	RequeueOrphanedSyncJobs(ctx context.Context) ([]int64, error)
+	// Queues the job again, without using up an attempt, e.g. when the worker running it shuts down.
+	RequeueSyncJob(ctx context.Context, id int64) (int64, error)
+	// Queues the job again after a failed attempt, to be retried after the backoff of its sync type.
+	RetrySyncJob(ctx context.Context, id int64) (sql.NullTime, error)
+	SendWorkerHeartbeat(ctx context.Context, id uuid.UUID) (int64, error)
+	SetLatestKeepAliveForJob(ctx context.Context, id int64) (bool, error)
 	SetSyncJobStatus(ctx context.Context, arg SetSyncJobStatusParams) error
 	UpdateImportStatus(ctx context.Context, arg UpdateImportStatusParams) error
 	UpsertRepo(ctx context.Context, arg UpsertRepoParams) error
+	UpsertRepoSyncCheckpoint(ctx context.Context, arg UpsertRepoSyncCheckpointParams) error
 	UpsertWorkflowRunJobs(ctx context.Context, arg UpsertWorkflowRunJobsParams) error
 	UpsertWorkflowRuns(ctx context.Context, arg UpsertWorkflowRunsParams) error
 	UpsertWorkflowsInPublic(ctx context.Context, arg UpsertWorkflowsInPublicParams) error
@@ -36,34 +36,26 @@ DO UPDATE SET tags = (
 UPDATE mergestat.repo_imports SET last_import = now() WHERE id = $1;
 
 -- name: DequeueSyncJob :one
-WITH
-running AS (
-        SELECT 
-            rsq.id,
-            rstg.group
-        FROM mergestat.repo_sync_queue rsq
-        INNER JOIN mergestat.repo_sync_type_groups rstg ON rsq.type_group = rstg.group
-        WHERE status = 'RUNNING'
-),
-dequeued AS (
-   UPDATE mergestat.repo_sync_queue SET status = 'RUNNING'
-   WHERE id IN (   
-        SELECT rsq.id
-        FROM mergestat.repo_sync_queue rsq
-        INNER JOIN mergestat.repo_sync_type_groups rstg ON rsq.type_group = rstg.group
-        WHERE status = 'QUEUED'
-        AND rstg.concurrent_syncs > (SELECT COUNT(*) FROM running WHERE running.group = rstg.group)
-        ORDER BY rsq.priority ASC, rsq.created_at ASC, rsq.id ASC LIMIT 1 FOR UPDATE SKIP LOCKED
-   ) RETURNING id, created_at, status, repo_sync_id
+-- Dequeues the given job when the sqlq job executing it (see mergestat.enqueue_repo_sync_job) is picked up by the worker.
+-- A job left running by a worker that stopped sending heartbeats is dequeued again, e.g. when sqlq retries it before
+-- RequeueOrphanedSyncJobs queues it again; a job still running on a live worker never is.
+WITH dequeued AS (
+   UPDATE mergestat.repo_sync_queue rsq SET status = 'RUNNING', worker_id = @worker_id
+   WHERE rsq.id = @id AND (rsq.status = 'QUEUED' OR (rsq.status = 'RUNNING' AND NOT EXISTS (
+       SELECT 1 FROM mergestat.workers w WHERE w.id = rsq.worker_id AND w.last_heartbeat_at >= now() - '2 minutes'::interval
+   )))
+   RETURNING id, created_at, status, repo_sync_id, attempt
 )
 SELECT
     dequeued.*,
     repo_syncs.*,
     repos.repo,
     repos.ref,
-    repos.settings AS repo_settings
+    repos.settings AS repo_settings,
+    repo_sync_types.max_runtime
 FROM dequeued
 JOIN mergestat.repo_syncs ON mergestat.repo_syncs.id = dequeued.repo_sync_id
+JOIN mergestat.repo_sync_types ON mergestat.repo_sync_types.type = mergestat.repo_syncs.sync_type
 JOIN repos ON repos.id = mergestat.repo_syncs.repo_id
 ;
 
@@ -95,6 +87,9 @@ SELECT pgp_sym_decrypt(credentials, $1) FROM mergestat.service_auth_credentials
 -- We use a CTE here to retrieve all the repo_sync_jobs that were previously enqueued, to make sure that we *do not* re-enqueue anything new until the previously enqueued jobs are *completed*.
 -- This allows us to make sure all repo syncs complete before we reschedule a new batch.
 -- We have now also added a concept of type groups which allows us to apply this same logic but by each group type which is where the PARTITION BY clause comes into play
+-- Syncs depending on other syncs of the same repo (see mergestat.repo_sync_type_dependencies) are only enqueued once their prerequisites completed successfully.
+-- Only the syncs due according to their schedule (see ListSyncSchedules) are enqueued, or all of them if due_sync_ids is NULL.
+-- Syncs are only enqueued in the daily time window of their sync type, if any, and delayed by a random fraction of its jitter to spread them over time.
 -- name: EnqueueAllSyncs :exec
 WITH ranked_queue AS (
     SELECT
@@ -107,15 +102,19 @@ WITH ranked_queue AS (
     INNER JOIN mergestat.repo_sync_types AS rst ON rs.sync_type = rst.type
     WHERE rsq.done_at IS NULL
 )
-INSERT INTO mergestat.repo_sync_queue (repo_sync_id, status, priority, type_group)
+INSERT INTO mergestat.repo_sync_queue (repo_sync_id, status, priority, type_group, run_after)
 SELECT
     rs.id,
     'QUEUED' AS status,
 	rs.priority,
-    rst.type_group
+    rst.type_group,
+    now() + random() * rst.jitter AS run_after
 FROM mergestat.repo_syncs rs
 INNER JOIN mergestat.repo_sync_types AS rst ON rs.sync_type = rst.type
 WHERE schedule_enabled
+    -- syncs are only scheduled in the daily time window of their sync type, if any
+    AND mergestat.repo_sync_type_in_window(rs.sync_type)
+    AND (@due_sync_ids::UUID[] IS NULL OR rs.id = ANY(@due_sync_ids::UUID[]))
     AND id NOT IN (SELECT repo_sync_id FROM mergestat.repo_sync_queue WHERE status = 'RUNNING' OR status = 'QUEUED')
     AND NOT EXISTS (
         SELECT rq.done_at
@@ -124,22 +123,32 @@ WHERE schedule_enabled
             rq.rank_num >= 1
 	AND rq.type_group = rst.type_group
     )
+    -- syncs depending on other enabled syncs of the repo are only enqueued once those are done (see also mergestat.enqueue_dependent_syncs)
+    AND mergestat.repo_sync_prerequisites_done(rs.id)
 ORDER BY rs.priority, rs.sync_type desc
 ;
 
--- name: SetLatestKeepAliveForJob :exec
-UPDATE mergestat.repo_sync_queue SET last_keep_alive = now() WHERE id = $1;
+-- name: SetLatestKeepAliveForJob :one
+UPDATE mergestat.repo_sync_queue SET last_keep_alive = now() WHERE id = $1
+RETURNING cancel_requested_at IS NOT NULL AS cancel_requested;
 
 -- name: MarkSyncsAsTimedOut :many
 WITH timed_out_sync_jobs AS (
-    UPDATE mergestat.repo_sync_queue SET status = 'DONE' WHERE status = 'RUNNING' AND (
-        (last_keep_alive < now() - '10 minutes'::interval)
+    UPDATE mergestat.repo_sync_queue rsq SET status = 'FAILED'
+    FROM mergestat.repo_syncs rs, mergestat.repo_sync_types rst
+    WHERE rs.id = rsq.repo_sync_id AND rst.type = rs.sync_type AND rsq.status = 'RUNNING' AND (
+        (rsq.last_keep_alive < now() - '10 minutes'::interval)
         OR
-        (last_keep_alive IS NULL AND started_at < now() - '10 minutes'::interval)) -- if worker crashed before last_keep_alive was first set
-    RETURNING *
+        (rsq.last_keep_alive IS NULL AND rsq.started_at < now() - '10 minutes'::interval) -- if worker crashed before last_keep_alive was first set
+        OR
+        (rsq.started_at < now() - rst.max_runtime - '10 minutes'::interval)) -- if worker failed to cancel the job itself once it exceeded its max runtime
+    RETURNING rsq.id, rsq.started_at < now() - rst.max_runtime - '10 minutes'::interval AS exceeded_max_runtime, rst.max_runtime
 )
 INSERT INTO mergestat.repo_sync_logs (repo_sync_queue_id, log_type, message)
-SELECT id, 'ERROR', 'No response from job within reasonable interval. Timing out.' FROM timed_out_sync_jobs
+SELECT id, 'ERROR', CASE
+    WHEN exceeded_max_runtime THEN 'Job exceeded the maximum runtime of its sync type (' || max_runtime || '). Timing out.'
+    ELSE 'No response from job within reasonable interval. Timing out.'
+END FROM timed_out_sync_jobs
 RETURNING repo_sync_queue_id
 ;
 
@@ -415,3 +424,137 @@ WHERE dq.id = @id;
 
 -- name: EnableContainerSync :exec
 SELECT mergestat.enable_container_sync(@RepoID::UUID, @ContainerImageID::UUID);
+
+-- name: ListRepoSyncCheckpoints :many
+SELECT * FROM mergestat.repo_sync_checkpoints WHERE repo_sync_id = @repo_sync_id;
+
+-- name: UpsertRepoSyncCheckpoint :exec
+INSERT INTO mergestat.repo_sync_checkpoints (repo_sync_id, ref, commit_hash, settings_hash, failed_paths) VALUES (@repo_sync_id, @ref, @commit_hash, @settings_hash, COALESCE(@failed_paths::TEXT[], '{}'))
+ON CONFLICT (repo_sync_id, ref) DO UPDATE SET commit_hash = EXCLUDED.commit_hash, settings_hash = EXCLUDED.settings_hash, failed_paths = EXCLUDED.failed_paths, updated_at = now();
+
+-- name: DeleteRepoSyncCheckpoints :exec
+DELETE FROM mergestat.repo_sync_checkpoints WHERE repo_sync_id = @repo_sync_id;
+
+-- name: GetRepoSyncTypeSettingsSchema :one
+SELECT settings_schema FROM mergestat.repo_sync_types WHERE type = @type;
+
+-- name: GetSyncJobRetryPolicy :one
+SELECT rst.retry_max_attempts, rst.retry_on
+FROM mergestat.repo_sync_queue rsq
+INNER JOIN mergestat.repo_syncs rs ON rs.id = rsq.repo_sync_id
+INNER JOIN mergestat.repo_sync_types rst ON rst.type = rs.sync_type
+WHERE rsq.id = @id;
+
+-- name: RetrySyncJob :one
+-- Queues the job again after a failed attempt, to be retried after the backoff of its sync type.
+UPDATE mergestat.repo_sync_queue rsq SET
+    status = 'QUEUED',
+    worker_id = NULL,
+    attempt = rsq.attempt + 1,
+    run_after = now() + LEAST(rst.retry_backoff_max, rst.retry_backoff_base * power(2, rsq.attempt - 1))
+FROM mergestat.repo_syncs rs, mergestat.repo_sync_types rst
+WHERE rsq.id = @id AND rsq.status = 'RUNNING' AND rs.id = rsq.repo_sync_id AND rst.type = rs.sync_type
+RETURNING rsq.run_after;
+
+-- name: CountSyncJobsByStatus :many
+SELECT rs.sync_type, rsq.status, COUNT(*) AS jobs
+FROM mergestat.repo_sync_queue rsq
+INNER JOIN mergestat.repo_syncs rs ON rs.id = rsq.repo_sync_id
+WHERE rsq.status IN ('QUEUED', 'RUNNING')
+GROUP BY rs.sync_type, rsq.status;
+
+-- name: ListEnabledSyncTypes :many
+SELECT DISTINCT sync_type FROM mergestat.repo_syncs WHERE schedule_enabled;
+
+-- name: ListSyncSchedules :many
+-- Lists the enabled syncs with their effective schedule (their own, else the default of their sync type) and the time they were last enqueued at.
+SELECT
+    rs.id,
+    rs.sync_type,
+    COALESCE(rs.schedule, rst.schedule) AS schedule,
+    COALESCE(rs.min_interval, rst.min_interval) AS min_interval,
+    rsq.last_enqueued_at
+FROM mergestat.repo_syncs AS rs
+INNER JOIN mergestat.repo_sync_types AS rst ON rs.sync_type = rst.type
+LEFT JOIN (
+    SELECT repo_sync_id, max(created_at)::TIMESTAMPTZ AS last_enqueued_at FROM mergestat.repo_sync_queue GROUP BY repo_sync_id
+) AS rsq ON rsq.repo_sync_id = rs.id
+WHERE rs.schedule_enabled;
+
+-- name: RegisterWorker :exec
+INSERT INTO mergestat.workers (id, hostname, version, concurrency, capabilities, sync_types)
+VALUES (@id, @hostname, @version, @concurrency, @capabilities::TEXT[], @sync_types::TEXT[])
+ON CONFLICT (id) DO UPDATE SET
+    hostname = EXCLUDED.hostname,
+    version = EXCLUDED.version,
+    concurrency = EXCLUDED.concurrency,
+    capabilities = EXCLUDED.capabilities,
+    sync_types = EXCLUDED.sync_types,
+    last_heartbeat_at = now();
+
+-- name: DeleteWorker :exec
+DELETE FROM mergestat.workers WHERE id = @id;
+
+-- name: SendWorkerHeartbeat :execrows
+UPDATE mergestat.workers SET last_heartbeat_at = now() WHERE id = @id;
+
+-- name: DeleteDeadWorkers :execrows
+DELETE FROM mergestat.workers WHERE last_heartbeat_at < now() - '1 hour'::interval;
+
+-- name: RequeueOrphanedSyncJobs :many
+-- Re-queues the jobs left running by workers that stopped sending heartbeats (or were removed), logging which worker ran them.
+WITH orphaned AS (
+    SELECT rsq.id, rsq.worker_id, w.hostname
+    FROM mergestat.repo_sync_queue rsq
+    LEFT JOIN mergestat.workers w ON w.id = rsq.worker_id
+    WHERE rsq.status = 'RUNNING' AND rsq.worker_id IS NOT NULL
+        AND (w.id IS NULL OR w.last_heartbeat_at < now() - '2 minutes'::interval)
+    FOR UPDATE OF rsq SKIP LOCKED
+),
+requeued AS (
+    UPDATE mergestat.repo_sync_queue rsq SET status = 'QUEUED', worker_id = NULL, last_keep_alive = NULL
+    FROM orphaned
+    WHERE rsq.id = orphaned.id
+    RETURNING rsq.id, orphaned.worker_id, orphaned.hostname
+)
+INSERT INTO mergestat.repo_sync_logs (repo_sync_queue_id, log_type, message)
+SELECT id, 'WARNING', 'Worker ' || worker_id || COALESCE(' (' || hostname || ')', '') || ' stopped sending heartbeats while running the job. Re-queuing it.'
+FROM requeued
+RETURNING repo_sync_queue_id;
+
+-- name: RequeueSyncJob :execrows
+-- Queues the job again, without using up an attempt, e.g. when the worker running it shuts down.
+UPDATE mergestat.repo_sync_queue SET status = 'QUEUED', worker_id = NULL
+WHERE id = @id AND status = 'RUNNING';
+
+-- name: DeleteOrphanedGitBlobs :execrows
+-- Deletes a batch of the blobs no file references anymore. Blobs locked by the syncs upserting them are skipped, so that they aren't deleted before the files referencing them are inserted.
+DELETE FROM public.git_blobs WHERE sha IN (
+    SELECT b.sha FROM public.git_blobs b
+    WHERE NOT EXISTS (SELECT 1 FROM public.git_file_entries f WHERE f.blob_sha = b.sha)
+    LIMIT @lim
+    FOR UPDATE SKIP LOCKED
+);
+
+-- name: FailUnrunnableSyncJobs :many
+-- Marks as FAILED the queued jobs no live worker can run (see mergestat.sync_type_runnable), e.g. when the workers able to run them were stopped after they were queued.
+WITH unrunnable AS (
+    UPDATE mergestat.repo_sync_queue rsq SET status = 'FAILED', done_at = now()
+    FROM mergestat.repo_syncs rs
+    WHERE rsq.status = 'QUEUED' AND rs.id = rsq.repo_sync_id AND NOT mergestat.sync_type_runnable(rs.sync_type)
+    RETURNING rsq.id, rsq.sqlq_job_id, rs.sync_type
+), canceled AS (
+    UPDATE sqlq.jobs SET status = 'cancelled', completed_at = now()
+    FROM unrunnable WHERE jobs.id = unrunnable.sqlq_job_id AND jobs.status = 'pending'
+)
+INSERT INTO mergestat.repo_sync_logs (repo_sync_queue_id, log_type, message)
+SELECT id, 'ERROR', 'no worker can run syncs of type ' || sync_type || ', see the sync types of the workers in mergestat.workers'
+FROM unrunnable
+RETURNING repo_sync_queue_id;
+
+-- name: EnqueueStrandedSyncJobs :execrows
+-- Creates a new sqlq job for the queued jobs whose sqlq job ended without running them (e.g. the worker that picked it up died before dequeuing the job), as sqlq doesn't retry it. The jobs no worker can run are left to FailUnrunnableSyncJobs.
+UPDATE mergestat.repo_sync_queue rsq SET sqlq_job_id = mergestat.enqueue_repo_sync_job(rsq)
+FROM mergestat.repo_syncs rs
+WHERE rsq.status = 'QUEUED' AND rs.id = rsq.repo_sync_id AND mergestat.sync_type_runnable(rs.sync_type)
+    AND NOT EXISTS (SELECT 1 FROM sqlq.jobs j WHERE j.id = rsq.sqlq_job_id AND j.status IN ('pending', 'running', 'cancelling'));
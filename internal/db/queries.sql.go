@@ -43,6 +43,52 @@ func (q *Queries) CleanOldRepoSyncQueue(ctx context.Context, dollar_1 int32) err
 	return err
 }
 
+const countSyncJobsByStatus = `-- name: CountSyncJobsByStatus :many
+SELECT rs.sync_type, rsq.status, COUNT(*) AS jobs
+FROM mergestat.repo_sync_queue rsq
+INNER JOIN mergestat.repo_syncs rs ON rs.id = rsq.repo_sync_id
+WHERE rsq.status IN ('QUEUED', 'RUNNING')
+GROUP BY rs.sync_type, rsq.status
+`
+
+type CountSyncJobsByStatusRow struct {
+	SyncType string
+	Status   string
+	Jobs     int64
+}
+
+func (q *Queries) CountSyncJobsByStatus(ctx context.Context) ([]CountSyncJobsByStatusRow, error) {
+	rows, err := q.db.Query(ctx, countSyncJobsByStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountSyncJobsByStatusRow
+	for rows.Next() {
+		var i CountSyncJobsByStatusRow
+		if err := rows.Scan(&i.SyncType, &i.Status, &i.Jobs); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteDeadWorkers = `-- name: DeleteDeadWorkers :execrows
+DELETE FROM mergestat.workers WHERE last_heartbeat_at < now() - '1 hour'::interval
+`
+
+func (q *Queries) DeleteDeadWorkers(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteDeadWorkers)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const deleteGitHubRepoInfo = `-- name: DeleteGitHubRepoInfo :exec
 DELETE FROM public.github_repo_info WHERE repo_id = $1
 `
@@ -52,6 +98,24 @@ func (q *Queries) DeleteGitHubRepoInfo(ctx context.Context, repoID uuid.UUID) er
 	return err
 }
 
+const deleteOrphanedGitBlobs = `-- name: DeleteOrphanedGitBlobs :execrows
+DELETE FROM public.git_blobs WHERE sha IN (
+    SELECT b.sha FROM public.git_blobs b
+    WHERE NOT EXISTS (SELECT 1 FROM public.git_file_entries f WHERE f.blob_sha = b.sha)
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+`
+
+// Deletes a batch of the blobs no file references anymore. Blobs locked by the syncs upserting them are skipped, so that they aren't deleted before the files referencing them are inserted.
+func (q *Queries) DeleteOrphanedGitBlobs(ctx context.Context, lim int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteOrphanedGitBlobs, lim)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const deleteRemovedRepos = `-- name: DeleteRemovedRepos :exec
 DELETE FROM public.repos WHERE repo_import_id = $1::uuid AND NOT(repo = ANY($2::TEXT[]))
 `
@@ -66,35 +130,42 @@ func (q *Queries) DeleteRemovedRepos(ctx context.Context, arg DeleteRemovedRepos
 	return err
 }
 
+const deleteRepoSyncCheckpoints = `-- name: DeleteRepoSyncCheckpoints :exec
+DELETE FROM mergestat.repo_sync_checkpoints WHERE repo_sync_id = $1
+`
+
+func (q *Queries) DeleteRepoSyncCheckpoints(ctx context.Context, repoSyncID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteRepoSyncCheckpoints, repoSyncID)
+	return err
+}
+
+const deleteWorker = `-- name: DeleteWorker :exec
+DELETE FROM mergestat.workers WHERE id = $1
+`
+
+func (q *Queries) DeleteWorker(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteWorker, id)
+	return err
+}
+
 const dequeueSyncJob = `-- name: DequeueSyncJob :one
-WITH
-running AS (
-        SELECT 
-            rsq.id,
-            rstg.group
-        FROM mergestat.repo_sync_queue rsq
-        INNER JOIN mergestat.repo_sync_type_groups rstg ON rsq.type_group = rstg.group
-        WHERE status = 'RUNNING'
-),
-dequeued AS (
-   UPDATE mergestat.repo_sync_queue SET status = 'RUNNING'
-   WHERE id IN (   
-        SELECT rsq.id
-        FROM mergestat.repo_sync_queue rsq
-        INNER JOIN mergestat.repo_sync_type_groups rstg ON rsq.type_group = rstg.group
-        WHERE status = 'QUEUED'
-        AND rstg.concurrent_syncs > (SELECT COUNT(*) FROM running WHERE running.group = rstg.group)
-        ORDER BY rsq.priority ASC, rsq.created_at ASC, rsq.id ASC LIMIT 1 FOR UPDATE SKIP LOCKED
-   ) RETURNING id, created_at, status, repo_sync_id
+WITH dequeued AS (
+   UPDATE mergestat.repo_sync_queue rsq SET status = 'RUNNING', worker_id = $1
+   WHERE rsq.id = $2 AND (rsq.status = 'QUEUED' OR (rsq.status = 'RUNNING' AND NOT EXISTS (
+       SELECT 1 FROM mergestat.workers w WHERE w.id = rsq.worker_id AND w.last_heartbeat_at >= now() - '2 minutes'::interval
+   )))
+   RETURNING id, created_at, status, repo_sync_id, attempt
 )
 SELECT
-    dequeued.id, dequeued.created_at, dequeued.status, dequeued.repo_sync_id,
-    repo_syncs.repo_id, repo_syncs.sync_type, repo_syncs.settings, repo_syncs.id, repo_syncs.schedule_enabled, repo_syncs.priority, repo_syncs.last_completed_repo_sync_queue_id,
+    dequeued.id, dequeued.created_at, dequeued.status, dequeued.repo_sync_id, dequeued.attempt,
+    repo_syncs.repo_id, repo_syncs.sync_type, repo_syncs.settings, repo_syncs.id, repo_syncs.schedule_enabled, repo_syncs.priority, repo_syncs.last_completed_repo_sync_queue_id, repo_syncs.schedule, repo_syncs.min_interval,
     repos.repo,
     repos.ref,
-    repos.settings AS repo_settings
+    repos.settings AS repo_settings,
+    repo_sync_types.max_runtime
 FROM dequeued
 JOIN mergestat.repo_syncs ON mergestat.repo_syncs.id = dequeued.repo_sync_id
+JOIN mergestat.repo_sync_types ON mergestat.repo_sync_types.type = mergestat.repo_syncs.sync_type
 JOIN repos ON repos.id = mergestat.repo_syncs.repo_id
 `
 
@@ -103,6 +174,7 @@ type DequeueSyncJobRow struct {
 	CreatedAt                    time.Time
 	Status                       string
 	RepoSyncID                   uuid.UUID
+	Attempt                      int32
 	RepoID                       uuid.UUID
 	SyncType                     string
 	Settings                     pgtype.JSONB
@@ -110,19 +182,31 @@ type DequeueSyncJobRow struct {
 	ScheduleEnabled              bool
 	Priority                     int32
 	LastCompletedRepoSyncQueueID sql.NullInt64
+	Schedule                     sql.NullString
+	MinInterval                  pgtype.Interval
 	Repo                         string
 	Ref                          sql.NullString
 	RepoSettings                 pgtype.JSONB
+	MaxRuntime                   pgtype.Interval
+}
+
+type DequeueSyncJobParams struct {
+	WorkerID uuid.UUID
+	ID       int64
 }
 
-func (q *Queries) DequeueSyncJob(ctx context.Context) (DequeueSyncJobRow, error) {
-	row := q.db.QueryRow(ctx, dequeueSyncJob)
+// Dequeues the given job when the sqlq job executing it (see mergestat.enqueue_repo_sync_job) is picked up by the worker.
+// A job left running by a worker that stopped sending heartbeats is dequeued again, e.g. when sqlq retries it before
+// RequeueOrphanedSyncJobs queues it again; a job still running on a live worker never is.
+func (q *Queries) DequeueSyncJob(ctx context.Context, arg DequeueSyncJobParams) (DequeueSyncJobRow, error) {
+	row := q.db.QueryRow(ctx, dequeueSyncJob, arg.WorkerID, arg.ID)
 	var i DequeueSyncJobRow
 	err := row.Scan(
 		&i.ID,
 		&i.CreatedAt,
 		&i.Status,
 		&i.RepoSyncID,
+		&i.Attempt,
 		&i.RepoID,
 		&i.SyncType,
 		&i.Settings,
@@ -130,9 +214,12 @@ func (q *Queries) DequeueSyncJob(ctx context.Context) (DequeueSyncJobRow, error)
 		&i.ScheduleEnabled,
 		&i.Priority,
 		&i.LastCompletedRepoSyncQueueID,
+		&i.Schedule,
+		&i.MinInterval,
 		&i.Repo,
 		&i.Ref,
 		&i.RepoSettings,
+		&i.MaxRuntime,
 	)
 	return i, err
 }
@@ -163,15 +250,19 @@ WITH ranked_queue AS (
     INNER JOIN mergestat.repo_sync_types AS rst ON rs.sync_type = rst.type
     WHERE rsq.done_at IS NULL
 )
-INSERT INTO mergestat.repo_sync_queue (repo_sync_id, status, priority, type_group)
+INSERT INTO mergestat.repo_sync_queue (repo_sync_id, status, priority, type_group, run_after)
 SELECT
     rs.id,
     'QUEUED' AS status,
 	rs.priority,
-    rst.type_group
+    rst.type_group,
+    now() + random() * rst.jitter AS run_after
 FROM mergestat.repo_syncs rs
 INNER JOIN mergestat.repo_sync_types AS rst ON rs.sync_type = rst.type
 WHERE schedule_enabled
+    -- syncs are only scheduled in the daily time window of their sync type, if any
+    AND mergestat.repo_sync_type_in_window(rs.sync_type)
+    AND ($1::UUID[] IS NULL OR rs.id = ANY($1::UUID[]))
     AND id NOT IN (SELECT repo_sync_id FROM mergestat.repo_sync_queue WHERE status = 'RUNNING' OR status = 'QUEUED')
     AND NOT EXISTS (
         SELECT rq.done_at
@@ -180,17 +271,75 @@ WHERE schedule_enabled
             rq.rank_num >= 1
 	AND rq.type_group = rst.type_group
     )
+    -- syncs depending on other enabled syncs of the repo are only enqueued once those are done (see also mergestat.enqueue_dependent_syncs)
+    AND mergestat.repo_sync_prerequisites_done(rs.id)
 ORDER BY rs.priority, rs.sync_type desc
 `
 
 // We use a CTE here to retrieve all the repo_sync_jobs that were previously enqueued, to make sure that we *do not* re-enqueue anything new until the previously enqueued jobs are *completed*.
 // This allows us to make sure all repo syncs complete before we reschedule a new batch.
 // We have now also added a concept of type groups which allows us to apply this same logic but by each group type which is where the PARTITION BY clause comes into play
-func (q *Queries) EnqueueAllSyncs(ctx context.Context) error {
-	_, err := q.db.Exec(ctx, enqueueAllSyncs)
+// Syncs depending on other syncs of the same repo (see mergestat.repo_sync_type_dependencies) are only enqueued once their prerequisites completed successfully.
+// Only the syncs due according to their schedule (see ListSyncSchedules) are enqueued, or all of them if due_sync_ids is NULL.
+// Syncs are only enqueued in the daily time window of their sync type, if any, and delayed by a random fraction of its jitter to spread them over time.
+func (q *Queries) EnqueueAllSyncs(ctx context.Context, dueSyncIds []uuid.UUID) error {
+	_, err := q.db.Exec(ctx, enqueueAllSyncs, dueSyncIds)
 	return err
 }
 
+const enqueueStrandedSyncJobs = `-- name: EnqueueStrandedSyncJobs :execrows
+UPDATE mergestat.repo_sync_queue rsq SET sqlq_job_id = mergestat.enqueue_repo_sync_job(rsq)
+FROM mergestat.repo_syncs rs
+WHERE rsq.status = 'QUEUED' AND rs.id = rsq.repo_sync_id AND mergestat.sync_type_runnable(rs.sync_type)
+    AND NOT EXISTS (SELECT 1 FROM sqlq.jobs j WHERE j.id = rsq.sqlq_job_id AND j.status IN ('pending', 'running', 'cancelling'))
+`
+
+// Creates a new sqlq job for the queued jobs whose sqlq job ended without running them (e.g. the worker that picked it up died before dequeuing the job), as sqlq doesn't retry it. The jobs no worker can run are left to FailUnrunnableSyncJobs.
+func (q *Queries) EnqueueStrandedSyncJobs(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, enqueueStrandedSyncJobs)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const failUnrunnableSyncJobs = `-- name: FailUnrunnableSyncJobs :many
+WITH unrunnable AS (
+    UPDATE mergestat.repo_sync_queue rsq SET status = 'FAILED', done_at = now()
+    FROM mergestat.repo_syncs rs
+    WHERE rsq.status = 'QUEUED' AND rs.id = rsq.repo_sync_id AND NOT mergestat.sync_type_runnable(rs.sync_type)
+    RETURNING rsq.id, rsq.sqlq_job_id, rs.sync_type
+), canceled AS (
+    UPDATE sqlq.jobs SET status = 'cancelled', completed_at = now()
+    FROM unrunnable WHERE jobs.id = unrunnable.sqlq_job_id AND jobs.status = 'pending'
+)
+INSERT INTO mergestat.repo_sync_logs (repo_sync_queue_id, log_type, message)
+SELECT id, 'ERROR', 'no worker can run syncs of type ' || sync_type || ', see the sync types of the workers in mergestat.workers'
+FROM unrunnable
+RETURNING repo_sync_queue_id
+`
+
+// Marks as FAILED the queued jobs no live worker can run (see mergestat.sync_type_runnable), e.g. when the workers able to run them were stopped after they were queued.
+func (q *Queries) FailUnrunnableSyncJobs(ctx context.Context) ([]int64, error) {
+	rows, err := q.db.Query(ctx, failUnrunnableSyncJobs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var repo_sync_queue_id int64
+		if err := rows.Scan(&repo_sync_queue_id); err != nil {
+			return nil, err
+		}
+		items = append(items, repo_sync_queue_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const fetchContainerSync = `-- name: FetchContainerSync :one
 SELECT sync.id, sync.repo_id,
     image.type AS image_type, image.url AS image_url, image.version AS image_version,
@@ -338,6 +487,17 @@ func (q *Queries) GetRepoImportByID(ctx context.Context, id uuid.UUID) (Mergesta
 	return i, err
 }
 
+const getRepoSyncTypeSettingsSchema = `-- name: GetRepoSyncTypeSettingsSchema :one
+SELECT settings_schema FROM mergestat.repo_sync_types WHERE type = $1
+`
+
+func (q *Queries) GetRepoSyncTypeSettingsSchema(ctx context.Context, type_ string) (pgtype.JSONB, error) {
+	row := q.db.QueryRow(ctx, getRepoSyncTypeSettingsSchema, type_)
+	var settings_schema pgtype.JSONB
+	err := row.Scan(&settings_schema)
+	return settings_schema, err
+}
+
 const getRepoUrlFromImport = `-- name: GetRepoUrlFromImport :many
 SELECT repo FROM public.repos WHERE repo_import_id = $1::uuid
 `
@@ -362,6 +522,26 @@ func (q *Queries) GetRepoUrlFromImport(ctx context.Context, importid uuid.UUID)
 	return items, nil
 }
 
+const getSyncJobRetryPolicy = `-- name: GetSyncJobRetryPolicy :one
+SELECT rst.retry_max_attempts, rst.retry_on
+FROM mergestat.repo_sync_queue rsq
+INNER JOIN mergestat.repo_syncs rs ON rs.id = rsq.repo_sync_id
+INNER JOIN mergestat.repo_sync_types rst ON rst.type = rs.sync_type
+WHERE rsq.id = $1
+`
+
+type GetSyncJobRetryPolicyRow struct {
+	RetryMaxAttempts int32
+	RetryOn          []string
+}
+
+func (q *Queries) GetSyncJobRetryPolicy(ctx context.Context, id int64) (GetSyncJobRetryPolicyRow, error) {
+	row := q.db.QueryRow(ctx, getSyncJobRetryPolicy, id)
+	var i GetSyncJobRetryPolicyRow
+	err := row.Scan(&i.RetryMaxAttempts, &i.RetryOn)
+	return i, err
+}
+
 const insertGitHubRepoInfo = `-- name: InsertGitHubRepoInfo :exec
 INSERT INTO public.github_repo_info (
     repo_id, owner, name,
@@ -476,6 +656,30 @@ func (q *Queries) InsertSyncJobLog(ctx context.Context, arg InsertSyncJobLogPara
 	return err
 }
 
+const listEnabledSyncTypes = `-- name: ListEnabledSyncTypes :many
+SELECT DISTINCT sync_type FROM mergestat.repo_syncs WHERE schedule_enabled
+`
+
+func (q *Queries) ListEnabledSyncTypes(ctx context.Context) ([]string, error) {
+	rows, err := q.db.Query(ctx, listEnabledSyncTypes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var sync_type string
+		if err := rows.Scan(&sync_type); err != nil {
+			return nil, err
+		}
+		items = append(items, sync_type)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listRepoImportsDueForImport = `-- name: ListRepoImportsDueForImport :many
 WITH dequeued AS (
     UPDATE mergestat.repo_imports SET last_import_started_at = now()
@@ -533,6 +737,87 @@ func (q *Queries) ListRepoImportsDueForImport(ctx context.Context) ([]ListRepoIm
 	return items, nil
 }
 
+const listRepoSyncCheckpoints = `-- name: ListRepoSyncCheckpoints :many
+SELECT repo_sync_id, ref, commit_hash, updated_at, settings_hash, failed_paths FROM mergestat.repo_sync_checkpoints WHERE repo_sync_id = $1
+`
+
+func (q *Queries) ListRepoSyncCheckpoints(ctx context.Context, repoSyncID uuid.UUID) ([]MergestatRepoSyncCheckpoint, error) {
+	rows, err := q.db.Query(ctx, listRepoSyncCheckpoints, repoSyncID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MergestatRepoSyncCheckpoint
+	for rows.Next() {
+		var i MergestatRepoSyncCheckpoint
+		if err := rows.Scan(
+			&i.RepoSyncID,
+			&i.Ref,
+			&i.CommitHash,
+			&i.UpdatedAt,
+			&i.SettingsHash,
+			&i.FailedPaths,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSyncSchedules = `-- name: ListSyncSchedules :many
+SELECT
+    rs.id,
+    rs.sync_type,
+    COALESCE(rs.schedule, rst.schedule) AS schedule,
+    COALESCE(rs.min_interval, rst.min_interval) AS min_interval,
+    rsq.last_enqueued_at
+FROM mergestat.repo_syncs AS rs
+INNER JOIN mergestat.repo_sync_types AS rst ON rs.sync_type = rst.type
+LEFT JOIN (
+    SELECT repo_sync_id, max(created_at)::TIMESTAMPTZ AS last_enqueued_at FROM mergestat.repo_sync_queue GROUP BY repo_sync_id
+) AS rsq ON rsq.repo_sync_id = rs.id
+WHERE rs.schedule_enabled
+`
+
+type ListSyncSchedulesRow struct {
+	ID             uuid.UUID
+	SyncType       string
+	Schedule       sql.NullString
+	MinInterval    pgtype.Interval
+	LastEnqueuedAt sql.NullTime
+}
+
+// Lists the enabled syncs with their effective schedule (their own, else the default of their sync type) and the time they were last enqueued at.
+func (q *Queries) ListSyncSchedules(ctx context.Context) ([]ListSyncSchedulesRow, error) {
+	rows, err := q.db.Query(ctx, listSyncSchedules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSyncSchedulesRow
+	for rows.Next() {
+		var i ListSyncSchedulesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SyncType,
+			&i.Schedule,
+			&i.MinInterval,
+			&i.LastEnqueuedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const markRepoImportAsUpdated = `-- name: MarkRepoImportAsUpdated :exec
 UPDATE mergestat.repo_imports SET last_import = now() WHERE id = $1
 `
@@ -544,14 +829,21 @@ func (q *Queries) MarkRepoImportAsUpdated(ctx context.Context, id uuid.UUID) err
 
 const markSyncsAsTimedOut = `-- name: MarkSyncsAsTimedOut :many
 WITH timed_out_sync_jobs AS (
-    UPDATE mergestat.repo_sync_queue SET status = 'DONE' WHERE status = 'RUNNING' AND (
-        (last_keep_alive < now() - '10 minutes'::interval)
+    UPDATE mergestat.repo_sync_queue rsq SET status = 'FAILED'
+    FROM mergestat.repo_syncs rs, mergestat.repo_sync_types rst
+    WHERE rs.id = rsq.repo_sync_id AND rst.type = rs.sync_type AND rsq.status = 'RUNNING' AND (
+        (rsq.last_keep_alive < now() - '10 minutes'::interval)
+        OR
+        (rsq.last_keep_alive IS NULL AND rsq.started_at < now() - '10 minutes'::interval) -- if worker crashed before last_keep_alive was first set
         OR
-        (last_keep_alive IS NULL AND started_at < now() - '10 minutes'::interval)) -- if worker crashed before last_keep_alive was first set
-    RETURNING id, created_at, repo_sync_id, status, started_at, done_at, last_keep_alive, priority, type_group
+        (rsq.started_at < now() - rst.max_runtime - '10 minutes'::interval)) -- if worker failed to cancel the job itself once it exceeded its max runtime
+    RETURNING rsq.id, rsq.started_at < now() - rst.max_runtime - '10 minutes'::interval AS exceeded_max_runtime, rst.max_runtime
 )
 INSERT INTO mergestat.repo_sync_logs (repo_sync_queue_id, log_type, message)
-SELECT id, 'ERROR', 'No response from job within reasonable interval. Timing out.' FROM timed_out_sync_jobs
+SELECT id, 'ERROR', CASE
+    WHEN exceeded_max_runtime THEN 'Job exceeded the maximum runtime of its sync type (' || max_runtime || '). Timing out.'
+    ELSE 'No response from job within reasonable interval. Timing out.'
+END FROM timed_out_sync_jobs
 RETURNING repo_sync_queue_id
 `
 
@@ -575,15 +867,138 @@ func (q *Queries) MarkSyncsAsTimedOut(ctx context.Context) ([]int64, error) {
 	return items, nil
 }
 
-const setLatestKeepAliveForJob = `-- name: SetLatestKeepAliveForJob :exec
-UPDATE mergestat.repo_sync_queue SET last_keep_alive = now() WHERE id = $1
+const registerWorker = `-- name: RegisterWorker :exec
+INSERT INTO mergestat.workers (id, hostname, version, concurrency, capabilities, sync_types)
+VALUES ($1, $2, $3, $4, $5::TEXT[], $6::TEXT[])
+ON CONFLICT (id) DO UPDATE SET
+    hostname = EXCLUDED.hostname,
+    version = EXCLUDED.version,
+    concurrency = EXCLUDED.concurrency,
+    capabilities = EXCLUDED.capabilities,
+    sync_types = EXCLUDED.sync_types,
+    last_heartbeat_at = now()
 `
 
-func (q *Queries) SetLatestKeepAliveForJob(ctx context.Context, id int64) error {
-	_, err := q.db.Exec(ctx, setLatestKeepAliveForJob, id)
+type RegisterWorkerParams struct {
+	ID           uuid.UUID
+	Hostname     string
+	Version      sql.NullString
+	Concurrency  int32
+	Capabilities []string
+	SyncTypes    []string
+}
+
+func (q *Queries) RegisterWorker(ctx context.Context, arg RegisterWorkerParams) error {
+	_, err := q.db.Exec(ctx, registerWorker,
+		arg.ID,
+		arg.Hostname,
+		arg.Version,
+		arg.Concurrency,
+		arg.Capabilities,
+		arg.SyncTypes,
+	)
 	return err
 }
 
+const requeueOrphanedSyncJobs = `-- name: RequeueOrphanedSyncJobs :many
+WITH orphaned AS (
+    SELECT rsq.id, rsq.worker_id, w.hostname
+    FROM mergestat.repo_sync_queue rsq
+    LEFT JOIN mergestat.workers w ON w.id = rsq.worker_id
+    WHERE rsq.status = 'RUNNING' AND rsq.worker_id IS NOT NULL
+        AND (w.id IS NULL OR w.last_heartbeat_at < now() - '2 minutes'::interval)
+    FOR UPDATE OF rsq SKIP LOCKED
+),
+requeued AS (
+    UPDATE mergestat.repo_sync_queue rsq SET status = 'QUEUED', worker_id = NULL, last_keep_alive = NULL
+    FROM orphaned
+    WHERE rsq.id = orphaned.id
+    RETURNING rsq.id, orphaned.worker_id, orphaned.hostname
+)
+INSERT INTO mergestat.repo_sync_logs (repo_sync_queue_id, log_type, message)
+SELECT id, 'WARNING', 'Worker ' || worker_id || COALESCE(' (' || hostname || ')', '') || ' stopped sending heartbeats while running the job. Re-queuing it.'
+FROM requeued
+RETURNING repo_sync_queue_id
+`
+
+// Re-queues the jobs left running by workers that stopped sending heartbeats (or were removed), logging which worker ran them.
+func (q *Queries) RequeueOrphanedSyncJobs(ctx context.Context) ([]int64, error) {
+	rows, err := q.db.Query(ctx, requeueOrphanedSyncJobs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var repo_sync_queue_id int64
+		if err := rows.Scan(&repo_sync_queue_id); err != nil {
+			return nil, err
+		}
+		items = append(items, repo_sync_queue_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const requeueSyncJob = `-- name: RequeueSyncJob :execrows
+UPDATE mergestat.repo_sync_queue SET status = 'QUEUED', worker_id = NULL
+WHERE id = $1 AND status = 'RUNNING'
+`
+
+// Queues the job again, without using up an attempt, e.g. when the worker running it shuts down.
+func (q *Queries) RequeueSyncJob(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, requeueSyncJob, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const retrySyncJob = `-- name: RetrySyncJob :one
+UPDATE mergestat.repo_sync_queue rsq SET
+    status = 'QUEUED',
+    worker_id = NULL,
+    attempt = rsq.attempt + 1,
+    run_after = now() + LEAST(rst.retry_backoff_max, rst.retry_backoff_base * power(2, rsq.attempt - 1))
+FROM mergestat.repo_syncs rs, mergestat.repo_sync_types rst
+WHERE rsq.id = $1 AND rsq.status = 'RUNNING' AND rs.id = rsq.repo_sync_id AND rst.type = rs.sync_type
+RETURNING rsq.run_after
+`
+
+// Queues the job again after a failed attempt, to be retried after the backoff of its sync type.
+func (q *Queries) RetrySyncJob(ctx context.Context, id int64) (sql.NullTime, error) {
+	row := q.db.QueryRow(ctx, retrySyncJob, id)
+	var run_after sql.NullTime
+	err := row.Scan(&run_after)
+	return run_after, err
+}
+
+const sendWorkerHeartbeat = `-- name: SendWorkerHeartbeat :execrows
+UPDATE mergestat.workers SET last_heartbeat_at = now() WHERE id = $1
+`
+
+func (q *Queries) SendWorkerHeartbeat(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, sendWorkerHeartbeat, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setLatestKeepAliveForJob = `-- name: SetLatestKeepAliveForJob :one
+UPDATE mergestat.repo_sync_queue SET last_keep_alive = now() WHERE id = $1
+RETURNING cancel_requested_at IS NOT NULL AS cancel_requested
+`
+
+func (q *Queries) SetLatestKeepAliveForJob(ctx context.Context, id int64) (bool, error) {
+	row := q.db.QueryRow(ctx, setLatestKeepAliveForJob, id)
+	var cancel_requested bool
+	err := row.Scan(&cancel_requested)
+	return cancel_requested, err
+}
+
 const setSyncJobStatus = `-- name: SetSyncJobStatus :exec
 SELECT mergestat.set_sync_job_status($1::TEXT, $2::BIGINT)
 `
@@ -637,6 +1052,24 @@ func (q *Queries) UpsertRepo(ctx context.Context, arg UpsertRepoParams) error {
 	return err
 }
 
+const upsertRepoSyncCheckpoint = `-- name: UpsertRepoSyncCheckpoint :exec
+INSERT INTO mergestat.repo_sync_checkpoints (repo_sync_id, ref, commit_hash, settings_hash, failed_paths) VALUES ($1, $2, $3, $4, COALESCE($5::TEXT[], '{}'))
+ON CONFLICT (repo_sync_id, ref) DO UPDATE SET commit_hash = EXCLUDED.commit_hash, settings_hash = EXCLUDED.settings_hash, failed_paths = EXCLUDED.failed_paths, updated_at = now()
+`
+
+type UpsertRepoSyncCheckpointParams struct {
+	RepoSyncID   uuid.UUID
+	Ref          string
+	CommitHash   string
+	SettingsHash sql.NullString
+	FailedPaths  []string
+}
+
+func (q *Queries) UpsertRepoSyncCheckpoint(ctx context.Context, arg UpsertRepoSyncCheckpointParams) error {
+	_, err := q.db.Exec(ctx, upsertRepoSyncCheckpoint, arg.RepoSyncID, arg.Ref, arg.CommitHash, arg.SettingsHash, arg.FailedPaths)
+	return err
+}
+
 const upsertWorkflowRunJobs = `-- name: UpsertWorkflowRunJobs :exec
 WITH t AS (
 	INSERT INTO public.github_actions_workflow_run_jobs (
@@ -0,0 +1,91 @@
+package helper
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Trailer is a "key: value" line of the trailers at the end of a commit message, such as Signed-off-by
+type Trailer struct {
+	Key   string // key of the trailer, lower cased (e.g. co-authored-by)
+	Value string // value of the trailer, with its continuation lines joined by a space
+}
+
+var (
+	// trailerLine matches a trailer, its key being a single word or the BREAKING CHANGE footer of conventional commits
+	trailerLine = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*|BREAKING CHANGE)\s*:\s*(.*)$`)
+
+	// conventionalSubject matches the subject of a conventional commit, e.g. feat(parser)!: support arrays
+	conventionalSubject = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^()]*)\))?(!)?: \S`)
+
+	// identity matches an identity as written in trailers, e.g. Jane Doe <jane@example.com>
+	identity = regexp.MustCompile(`^(.*?)\s*<([^<>]*)>$`)
+)
+
+// ParseTrailers returns the trailers of a commit message, i.e. the lines of its last paragraph if all of them are
+// trailers (or their continuation lines), as git interpret-trailers does. The subject is never parsed as trailers.
+func ParseTrailers(message string) []Trailer {
+	var paragraphs = strings.Split(strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n")), "\n\n")
+	if len(paragraphs) < 2 {
+		return nil
+	}
+
+	var trailers []Trailer
+	for _, line := range strings.Split(strings.Trim(paragraphs[len(paragraphs)-1], "\n"), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue // comments are ignored, as git does
+		}
+
+		// lines starting with whitespace continue the value of the previous trailer
+		if trimmed := strings.TrimSpace(line); trimmed != line && len(trailers) > 0 {
+			trailers[len(trailers)-1].Value = strings.TrimSpace(trailers[len(trailers)-1].Value + " " + trimmed)
+			continue
+		}
+
+		var m = trailerLine.FindStringSubmatch(line)
+		if m == nil {
+			return nil
+		}
+		trailers = append(trailers, Trailer{Key: strings.ToLower(m[1]), Value: strings.TrimSpace(m[2])})
+	}
+
+	return trailers
+}
+
+// ParseIdentity splits the value of a trailer such as Co-authored-by into the name and email of the identity it
+// refers to. It returns false if the value isn't an identity.
+func ParseIdentity(value string) (name, email string, ok bool) {
+	var m = identity.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+// ConventionalCommit is the classification of a commit following the Conventional Commits specification
+// (see https://www.conventionalcommits.org)
+type ConventionalCommit struct {
+	Type     string // type of the commit, lower cased (e.g. feat or fix)
+	Scope    string // scope of the commit, if any
+	Breaking bool   // true if the commit introduces a breaking change
+}
+
+// ParseConventionalCommit classifies a commit by the subject of its message and its trailers, as parsed by
+// ParseTrailers. It returns false if the subject doesn't follow the Conventional Commits specification.
+func ParseConventionalCommit(message string, trailers []Trailer) (ConventionalCommit, bool) {
+	var subject, _, _ = strings.Cut(strings.TrimSpace(message), "\n")
+
+	var m = conventionalSubject.FindStringSubmatch(subject)
+	if m == nil {
+		return ConventionalCommit{}, false
+	}
+
+	var c = ConventionalCommit{Type: strings.ToLower(m[1]), Scope: strings.TrimSpace(m[2]), Breaking: m[3] == "!"}
+	for _, trailer := range trailers {
+		if trailer.Key == "breaking change" || trailer.Key == "breaking-change" {
+			c.Breaking = true
+		}
+	}
+
+	return c, true
+}
@@ -0,0 +1,96 @@
+package helper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTrailers(t *testing.T) {
+	type testArgs struct {
+		description string
+		message     string
+		want        []Trailer
+	}
+
+	tests := []testArgs{{
+		description: "trailers in the last paragraph",
+		message:     "fix: handle empty refs\n\nSome details.\n\nSigned-off-by: Jane Doe <jane@example.com>\nCo-Authored-By: John Doe <john@example.com>\n",
+		want: []Trailer{
+			{Key: "signed-off-by", Value: "Jane Doe <jane@example.com>"},
+			{Key: "co-authored-by", Value: "John Doe <john@example.com>"},
+		},
+	}, {
+		description: "continuation lines and breaking change footer",
+		message:     "feat!: drop v1\n\nBREAKING CHANGE: the v1 API\n  is removed\nReviewed-by: Jane Doe <jane@example.com>",
+		want: []Trailer{
+			{Key: "breaking change", Value: "the v1 API is removed"},
+			{Key: "reviewed-by", Value: "Jane Doe <jane@example.com>"},
+		},
+	}, {
+		description: "last paragraph is not only trailers",
+		message:     "chore: bump deps\n\nSee: the changelog\nfor the details",
+		want:        nil,
+	}, {
+		description: "subject is never a trailer",
+		message:     "Fixes: something",
+		want:        nil,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if got := ParseTrailers(test.message); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ParseTrailers = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseIdentity(t *testing.T) {
+	name, email, ok := ParseIdentity("Jane Doe <jane@example.com>")
+	if !ok || name != "Jane Doe" || email != "jane@example.com" {
+		t.Errorf("ParseIdentity = %q, %q, %v", name, email, ok)
+	}
+
+	if _, _, ok := ParseIdentity("jane@example.com"); ok {
+		t.Errorf("ParseIdentity of a bare email = true, want false")
+	}
+}
+
+func TestParseConventionalCommit(t *testing.T) {
+	type testArgs struct {
+		description string
+		message     string
+		want        ConventionalCommit
+		wantOK      bool
+	}
+
+	tests := []testArgs{{
+		description: "type only",
+		message:     "fix: handle empty refs",
+		want:        ConventionalCommit{Type: "fix"},
+		wantOK:      true,
+	}, {
+		description: "type, scope and breaking change marker",
+		message:     "Feat(parser)!: support arrays\n\nbody",
+		want:        ConventionalCommit{Type: "feat", Scope: "parser", Breaking: true},
+		wantOK:      true,
+	}, {
+		description: "breaking change footer",
+		message:     "refactor(api): rename fields\n\nBREAKING-CHANGE: fields are renamed",
+		want:        ConventionalCommit{Type: "refactor", Scope: "api", Breaking: true},
+		wantOK:      true,
+	}, {
+		description: "not a conventional commit",
+		message:     "Merge branch 'main' into feature",
+		wantOK:      false,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			got, ok := ParseConventionalCommit(test.message, ParseTrailers(test.message))
+			if ok != test.wantOK || got != test.want {
+				t.Errorf("ParseConventionalCommit = %+v, %v, want %+v, %v", got, ok, test.want, test.wantOK)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package helper
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
 	"strconv"
@@ -10,8 +11,10 @@ import (
 	"time"
 
 	"github.com/google/go-github/v50/github"
+	"github.com/mergestat/mergestat/internal/metrics"
 	"github.com/mergestat/mergestat/queries"
 	"github.com/rs/zerolog"
+	"golang.org/x/oauth2"
 )
 
 // GetRepoOwnerAndRepoName extracts the owner and repo name from a GitHub-like repo url
@@ -69,6 +72,42 @@ func GetRepositoryURL(r *github.Repository) *string {
 	return r.URL
 }
 
+// NewGitHubClient returns a client of the GitHub REST API, authenticated with the given token unless it's empty.
+// The remaining requests of the rate limit, as reported in every response of the API, are recorded in the metrics.
+func NewGitHubClient(ctx context.Context, token string) *github.Client {
+	var httpClient = &http.Client{}
+	if token != "" {
+		httpClient = oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+	}
+
+	httpClient.Transport = &rateLimitTransport{base: httpClient.Transport}
+	return github.NewClient(httpClient)
+}
+
+// rateLimitTransport records the rate limit reported in the responses of the GitHub REST API
+// in metrics.GitHubRateLimitRemaining
+type rateLimitTransport struct {
+	base http.RoundTripper // http.DefaultTransport if nil
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var base = t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	var resp, err = base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		metrics.GitHubRateLimitRemaining.WithLabelValues("rest").Set(float64(remaining))
+	}
+
+	return resp, nil
+}
+
 func RestRatelimitHandler(ctx context.Context, resp *github.Response, l *zerolog.Logger, qry queries.Querier, impRunning bool) {
 	var remaining = resp.Rate.Remaining
 	var delay = 800 * time.Millisecond
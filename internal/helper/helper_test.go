@@ -1,12 +1,18 @@
 package helper
 
 import (
+	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"reflect"
 	"testing"
 
 	"github.com/google/go-github/v50/github"
+	"github.com/mergestat/mergestat/internal/metrics"
+	dto "github.com/prometheus/client_model/go"
 )
 
 func TestGetRepoOwnerAndRepoName(t *testing.T) {
@@ -163,3 +169,57 @@ func TestGetRepositoryURL(t *testing.T) {
 		})
 	}
 }
+
+func TestNewGitHubClientRecordsRateLimit(t *testing.T) {
+	type testArgs struct {
+		description string
+		remaining   string
+		want        float64
+	}
+
+	tests := []testArgs{
+		{
+			description: "remaining requests reported",
+			remaining:   "4321",
+			want:        4321,
+		},
+		{
+			description: "remaining requests not reported",
+			remaining:   "",
+			want:        4321,
+		},
+		{
+			description: "remaining requests exhausted",
+			remaining:   "0",
+			want:        0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if test.remaining != "" {
+					w.Header().Set("X-RateLimit-Remaining", test.remaining)
+				}
+				_, _ = w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			client := NewGitHubClient(context.Background(), "token")
+			client.BaseURL, _ = url.Parse(server.URL + "/")
+
+			if _, _, err := client.Repositories.Get(context.Background(), "mergestat", "mergestat"); err != nil {
+				t.Fatal(err)
+			}
+
+			var got dto.Metric
+			if err := metrics.GitHubRateLimitRemaining.WithLabelValues("rest").Write(&got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.GetGauge().GetValue() != test.want {
+				t.Fatalf("got %v remaining requests, want %v", got.GetGauge().GetValue(), test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// LineCounts are the number of lines of code, comment and blank lines of a source file
+type LineCounts struct {
+	Code    int
+	Comment int
+	Blank   int
+}
+
+// commentSyntax describes how comments are written in a language
+type commentSyntax struct {
+	line  []string    // prefixes of comments running until the end of the line
+	block [][2]string // start and end delimiters of comments spanning multiple lines
+}
+
+var (
+	cStyle    = commentSyntax{line: []string{"//"}, block: [][2]string{{"/*", "*/"}}}
+	hashStyle = commentSyntax{line: []string{"#"}}
+	sqlStyle  = commentSyntax{line: []string{"--"}, block: [][2]string{{"/*", "*/"}}}
+	xmlStyle  = commentSyntax{block: [][2]string{{"<!--", "-->"}}}
+	lispStyle = commentSyntax{line: []string{";"}}
+)
+
+// commentSyntaxes maps the languages, as detected by go-enry, to their comment syntax.
+// Lines of files in other languages are counted as code if they aren't blank.
+var commentSyntaxes = map[string]commentSyntax{
+	"C": cStyle, "C++": cStyle, "C#": cStyle, "Go": cStyle, "Java": cStyle, "JavaScript": cStyle, "TypeScript": cStyle,
+	"TSX": cStyle, "JSX": cStyle, "Kotlin": cStyle, "Rust": cStyle, "Scala": cStyle, "Swift": cStyle, "Dart": cStyle,
+	"Objective-C": cStyle, "Objective-C++": cStyle, "Groovy": cStyle, "Protocol Buffer": cStyle, "Solidity": cStyle,
+	"JSON with Comments": cStyle, "Zig": cStyle, "SCSS": cStyle, "Less": cStyle, "Jsonnet": cStyle,
+	"CSS": {block: [][2]string{{"/*", "*/"}}},
+	"PHP": {line: []string{"//", "#"}, block: [][2]string{{"/*", "*/"}}},
+	"HCL": {line: []string{"#", "//"}, block: [][2]string{{"/*", "*/"}}},
+
+	"Python": hashStyle, "Shell": hashStyle, "Ruby": hashStyle, "Perl": hashStyle, "R": hashStyle, "YAML": hashStyle,
+	"TOML": hashStyle, "Dockerfile": hashStyle, "Makefile": hashStyle, "CMake": hashStyle, "Elixir": hashStyle,
+	"Starlark": hashStyle, "GraphQL": hashStyle, "Nix": {line: []string{"#"}, block: [][2]string{{"/*", "*/"}}},
+	"PowerShell": {line: []string{"#"}, block: [][2]string{{"<#", "#>"}}},
+	"Julia":      {line: []string{"#"}, block: [][2]string{{"#=", "=#"}}},
+
+	"SQL": sqlStyle, "PLpgSQL": sqlStyle, "PLSQL": sqlStyle, "TSQL": sqlStyle,
+	"Lua":     {line: []string{"--"}, block: [][2]string{{"--[[", "]]"}}},
+	"Haskell": {line: []string{"--"}, block: [][2]string{{"{-", "-}"}}},
+	"Elm":     {line: []string{"--"}, block: [][2]string{{"{-", "-}"}}},
+
+	"HTML": xmlStyle, "XML": xmlStyle, "Vue": xmlStyle, "Svelte": xmlStyle, "Markdown": xmlStyle,
+
+	"Clojure": lispStyle, "Emacs Lisp": lispStyle, "Common Lisp": lispStyle, "Scheme": lispStyle,
+	"Erlang":     {line: []string{"%"}},
+	"Vim Script": {line: []string{"\""}},
+}
+
+// CountLines counts the lines of code, comment and blank lines of the contents of a file in the given language.
+// A line holding both code and a comment is counted as code, and blank lines are counted as such even inside
+// block comments. Comment delimiters inside string literals aren't recognized as such.
+func CountLines(language string, contents []byte) LineCounts {
+	var syntax = commentSyntaxes[language]
+	var counts LineCounts
+
+	var scanner = bufio.NewScanner(bytes.NewReader(contents))
+	scanner.Buffer(nil, len(contents)+1) // a single line can span the whole file
+
+	var end string // end delimiter of the block comment the current line is in, if any
+	for scanner.Scan() {
+		var line = strings.TrimSpace(scanner.Text())
+		if line == "" {
+			counts.Blank++
+			continue
+		}
+
+		var code, comment bool
+		for line != "" {
+			if end != "" {
+				comment = true
+				if i := strings.Index(line, end); i >= 0 {
+					line, end = strings.TrimSpace(line[i+len(end):]), ""
+					continue
+				}
+				break
+			}
+
+			if start, ok := blockStart(line, syntax); ok {
+				comment, end = true, start[1]
+				line = line[len(start[0]):]
+				continue
+			}
+
+			if hasAnyPrefix(line, syntax.line) {
+				comment = true
+				break
+			}
+
+			// the line holds code, up to the next block comment, if any
+			code = true
+			if i := nextBlockStart(line, syntax); i > 0 {
+				line = line[i:]
+				continue
+			}
+			break
+		}
+
+		switch {
+		case code:
+			counts.Code++
+		case comment:
+			counts.Comment++
+		}
+	}
+
+	return counts
+}
+
+// blockStart returns the delimiters of the block comment starting at the beginning of the line, if any
+func blockStart(line string, syntax commentSyntax) ([2]string, bool) {
+	for _, block := range syntax.block {
+		if strings.HasPrefix(line, block[0]) {
+			return block, true
+		}
+	}
+	return [2]string{}, false
+}
+
+// nextBlockStart returns the index of the first block comment in the line, or -1 if there is none
+func nextBlockStart(line string, syntax commentSyntax) int {
+	var next = -1
+	for _, block := range syntax.block {
+		if i := strings.Index(line, block[0]); i >= 0 && (next < 0 || i < next) {
+			next = i
+		}
+	}
+	return next
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package helper
+
+import (
+	"testing"
+)
+
+func TestCountLines(t *testing.T) {
+	type testArgs struct {
+		description string
+		language    string
+		contents    string
+		want        LineCounts
+	}
+
+	tests := []testArgs{{
+		description: "go with line and block comments",
+		language:    "Go",
+		contents:    "package main\n\n// main does nothing\nfunc main() {\n\t/* a block\n\n\t   comment */\n\tx := 1 // trailing comment\n\t/* inline */ y := 2\n}\n",
+		want:        LineCounts{Code: 5, Comment: 3, Blank: 2},
+	}, {
+		description: "python with hash comments",
+		language:    "Python",
+		contents:    "# comment\nimport os\n\n   \nprint(os.name)  # trailing\n",
+		want:        LineCounts{Code: 2, Comment: 1, Blank: 2},
+	}, {
+		description: "lua block comment starting like a line comment",
+		language:    "Lua",
+		contents:    "--[[ block\ncomment ]] print(1)\n-- line\n",
+		want:        LineCounts{Code: 1, Comment: 2, Blank: 0},
+	}, {
+		description: "unknown language counts non blank lines as code",
+		language:    "Text",
+		contents:    "hello\n\n# world",
+		want:        LineCounts{Code: 2, Comment: 0, Blank: 1},
+	}, {
+		description: "empty contents",
+		language:    "Go",
+		contents:    "",
+		want:        LineCounts{},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if got := CountLines(test.language, []byte(test.contents)); got != test.want {
+				t.Errorf("CountLines = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -109,3 +109,13 @@ func Int64ToSqlNullInt64(v *int64) sql.NullInt64 {
 
 	return sqlNullInt64
 }
+
+// IntervalToDuration converts an interval to a duration, counting months as 30 days, and reports false if it's NULL
+func IntervalToDuration(i pgtype.Interval) (time.Duration, bool) {
+	if i.Status != pgtype.Present {
+		return 0, false
+	}
+
+	const day = 24 * time.Hour
+	return time.Duration(i.Microseconds)*time.Microsecond + time.Duration(i.Days)*day + time.Duration(i.Months)*30*day, true
+}
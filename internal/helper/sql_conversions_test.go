@@ -136,3 +136,37 @@ func TestInt64ToSqlNullInt64(t *testing.T) {
 		})
 	}
 }
+
+func TestIntervalToDuration(t *testing.T) {
+	type testArgs struct {
+		value       pgtype.Interval
+		description string
+		want        time.Duration
+		wantOk      bool
+	}
+
+	tests := []testArgs{{
+		description: "null interval",
+		value:       pgtype.Interval{Status: pgtype.Null},
+		want:        0,
+		wantOk:      false,
+	}, {
+		description: "interval of days and microseconds",
+		value:       pgtype.Interval{Days: 1, Microseconds: int64(90 * time.Minute / time.Microsecond), Status: pgtype.Present},
+		want:        25*time.Hour + 30*time.Minute,
+		wantOk:      true,
+	}, {
+		description: "interval of months",
+		value:       pgtype.Interval{Months: 1, Status: pgtype.Present},
+		want:        30 * 24 * time.Hour,
+		wantOk:      true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if got, ok := IntervalToDuration(test.value); got != test.want || ok != test.wantOk {
+				t.Errorf("IntervalToDuration = %v, %v, want %v, %v", got, ok, test.want, test.wantOk)
+			}
+		})
+	}
+}
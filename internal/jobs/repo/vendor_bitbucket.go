@@ -101,7 +101,7 @@ func handleBitbucketImport(ctx context.Context, qry *db.Queries, imp db.FetchImp
 		}
 
 		// enqueue all newly added syncs
-		if err = qry.EnqueueAllSyncs(ctx); err != nil {
+		if err = qry.EnqueueAllSyncs(ctx, nil); err != nil {
 			return errors.Wrapf(err, "failed to enable default sync")
 		}
 	}
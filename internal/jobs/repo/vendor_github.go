@@ -4,14 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 
 	"github.com/google/go-github/v50/github"
 	"github.com/google/uuid"
 	"github.com/jackc/pgtype"
 	"github.com/mergestat/mergestat/internal/db"
+	"github.com/mergestat/mergestat/internal/helper"
 	"github.com/pkg/errors"
-	"golang.org/x/oauth2"
 )
 
 type fetchFunc func(ctx context.Context, page int) ([]*github.Repository, *github.Response, error)
@@ -24,13 +23,7 @@ func handleGithubImport(ctx context.Context, qry *db.Queries, imp db.FetchImport
 
 	var public = token == ""
 
-	var client *github.Client
-	if !public {
-		var tokenSource = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-		client = github.NewClient(oauth2.NewClient(ctx, tokenSource))
-	} else {
-		client = github.NewClient(&http.Client{})
-	}
+	var client = helper.NewGitHubClient(ctx, token)
 
 	var settings struct {
 		Type                   string      `json:"type"`
@@ -132,7 +125,7 @@ func handleGithubImport(ctx context.Context, qry *db.Queries, imp db.FetchImport
 		}
 
 		// enqueue all newly added syncs
-		if err = qry.EnqueueAllSyncs(ctx); err != nil {
+		if err = qry.EnqueueAllSyncs(ctx, nil); err != nil {
 			return errors.Wrapf(err, "failed to enable default sync")
 		}
 	}
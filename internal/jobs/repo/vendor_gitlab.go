@@ -121,7 +121,7 @@ func handleGitlabImport(ctx context.Context, qry *db.Queries, imp db.FetchImport
 		}
 
 		// enqueue all newly added syncs
-		if err = qry.EnqueueAllSyncs(ctx); err != nil {
+		if err = qry.EnqueueAllSyncs(ctx, nil); err != nil {
 			return errors.Wrapf(err, "failed to enable default sync")
 		}
 	}
@@ -0,0 +1,116 @@
+// Package leader elects a leader among the worker replicas, using a Postgres advisory lock, to run the periodic
+// routines that must only run in one of them at a time (e.g. the scheduler).
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// retryInterval is how often replicas that aren't the leader try to become it
+	retryInterval = 15 * time.Second
+
+	// heartbeatInterval is how often the leader checks the connection holding the lock is still alive
+	heartbeatInterval = 10 * time.Second
+)
+
+// Run runs the routines while the process is the leader of the named election, until the context is canceled.
+//
+// The leader is the replica holding the session-level advisory lock of the election, over a connection dedicated to
+// it (not taken from the pool). If it dies, its connection is closed and the lock released, and another replica becomes
+// the leader at its next attempt. If the leader fails to heartbeat over the connection holding the lock, it steps down:
+// the routines are stopped (their context is canceled) and the connection is closed once they all returned.
+//
+// Routines may briefly run in two replicas at once: if the server drops the session of the leader (e.g. on a network
+// partition), the lock is released right away, while the routines of the old leader keep running until its next failed
+// heartbeat (up to twice heartbeatInterval) and until they return. Routines must therefore tolerate briefly running
+// concurrently with themselves.
+func Run(ctx context.Context, logger *zerolog.Logger, pool *pgxpool.Pool, election string, routines ...func(context.Context)) {
+	var session *pgx.Conn
+	defer func() {
+		if session != nil {
+			_ = session.Close(context.Background())
+		}
+	}()
+
+	for {
+		var err error
+		if session == nil || session.IsClosed() {
+			session, err = pgx.ConnectConfig(ctx, pool.Config().ConnConfig)
+		}
+
+		if err == nil {
+			err = lead(ctx, logger, session, election, routines)
+		}
+
+		if err != nil && !errors.Is(err, context.Canceled) {
+			logger.Warn().AnErr("error", err).Msgf("not leading %s, retrying in %s", election, retryInterval)
+
+			// start over with a new connection, which also releases the lock if it's somehow still held
+			if session != nil {
+				_ = session.Close(context.Background())
+				session = nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// lead tries to acquire the lock of the election over the given connection and, if it succeeds, runs the routines
+// until the context is canceled or the connection is lost. The lock is held until the connection is closed.
+func lead(ctx context.Context, logger *zerolog.Logger, session *pgx.Conn, election string, routines []func(context.Context)) (err error) {
+	var acquired bool
+	if err = session.QueryRow(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", election).Scan(&acquired); err != nil {
+		return err
+	}
+
+	if !acquired {
+		return nil // another replica is the leader
+	}
+
+	logger.Info().Msgf("elected leader of %s", election)
+	defer logger.Info().Msgf("stepped down as leader of %s", election)
+
+	var g sync.WaitGroup
+	leaderCtx, cancel := context.WithCancel(ctx)
+	for _, routine := range routines {
+		g.Add(1)
+		go func(routine func(context.Context)) {
+			defer g.Done()
+			routine(leaderCtx)
+		}(routine)
+	}
+
+	// stop the routines, and wait for them to return, before the connection (and so the lock) is released
+	defer g.Wait()
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(heartbeatInterval):
+		}
+
+		pingCtx, cancelPing := context.WithTimeout(ctx, heartbeatInterval)
+		err = session.Ping(pingCtx)
+		cancelPing()
+
+		if err != nil {
+			return fmt.Errorf("heartbeat: %w", err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Package metrics defines the Prometheus metrics exported by the worker (see internal/ops for the HTTP server serving them)
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/mergestat/mergestat/internal/db"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "mergestat"
+
+// outcomes of a sync job, used as the value of the "outcome" label
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+	OutcomeCanceled  = "canceled"
+)
+
+var (
+	// JobsStarted counts the sync jobs started by the worker, per sync type
+	JobsStarted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_jobs_started_total",
+		Help:      "Number of sync jobs started by the worker.",
+	}, []string{"sync_type"})
+
+	// JobsSucceeded counts the sync jobs that completed successfully, per sync type
+	JobsSucceeded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_jobs_succeeded_total",
+		Help:      "Number of sync jobs that completed successfully.",
+	}, []string{"sync_type"})
+
+	// JobsFailed counts the attempts of sync jobs that failed, per sync type, including the ones that are retried
+	JobsFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_jobs_failed_total",
+		Help:      "Number of sync job attempts that failed, including the ones that are retried.",
+	}, []string{"sync_type"})
+
+	// JobDuration observes the time it took to execute sync jobs, per sync type and outcome
+	JobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "sync_job_duration_seconds",
+		Help:      "Time it took to execute sync jobs.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 16), // 1s to ~9h
+	}, []string{"sync_type", "outcome"})
+
+	// RowsInserted counts the rows inserted by sync jobs, per sync type and table
+	RowsInserted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_rows_inserted_total",
+		Help:      "Number of rows inserted by sync jobs.",
+	}, []string{"sync_type", "table"})
+
+	// GitHubRateLimitRemaining is the number of requests remaining in the current rate limit window of the GitHub API
+	GitHubRateLimitRemaining = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "github_rate_limit_remaining",
+		Help:      "Number of requests remaining in the current rate limit window of the GitHub API, as last reported by it.",
+	}, []string{"api"})
+)
+
+// queueDepth collects the number of queued and running sync jobs, per sync type, when scraped
+type queueDepth struct {
+	db   *db.Queries
+	desc *prometheus.Desc
+}
+
+// RegisterQueueDepth registers a collector of the depth of mergestat.repo_sync_queue, read from the database on every scrape
+func RegisterQueueDepth(queries *db.Queries) error {
+	return prometheus.Register(&queueDepth{
+		db: queries,
+		desc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "sync_queue_depth"),
+			"Number of sync jobs in the queue, by sync type and status (QUEUED or RUNNING).", []string{"sync_type", "status"}, nil),
+	})
+}
+
+func (q *queueDepth) Describe(ch chan<- *prometheus.Desc) { ch <- q.desc }
+
+func (q *queueDepth) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var counts, err = q.db.CountSyncJobsByStatus(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(q.desc, err)
+		return
+	}
+
+	for _, c := range counts {
+		ch <- prometheus.MustNewConstMetric(q.desc, prometheus.GaugeValue, float64(c.Jobs), c.SyncType, c.Status)
+	}
+}
@@ -6,10 +6,12 @@ package mocks
 
 import (
 	context "context"
+	sql "database/sql"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
+	pgtype "github.com/jackc/pgtype"
 	pgx "github.com/jackc/pgx/v4"
 	db "github.com/mergestat/mergestat/internal/db"
 	queries "github.com/mergestat/mergestat/queries"
@@ -81,6 +83,36 @@ func (mr *MockQuerierMockRecorder) CleanOldRepoSyncQueue(ctx, dollar_1 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CleanOldRepoSyncQueue", reflect.TypeOf((*MockQuerier)(nil).CleanOldRepoSyncQueue), ctx, dollar_1)
 }
 
+// CountSyncJobsByStatus mocks base method.
+func (m *MockQuerier) CountSyncJobsByStatus(ctx context.Context) ([]db.CountSyncJobsByStatusRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountSyncJobsByStatus", ctx)
+	ret0, _ := ret[0].([]db.CountSyncJobsByStatusRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountSyncJobsByStatus indicates an expected call of CountSyncJobsByStatus.
+func (mr *MockQuerierMockRecorder) CountSyncJobsByStatus(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountSyncJobsByStatus", reflect.TypeOf((*MockQuerier)(nil).CountSyncJobsByStatus), ctx)
+}
+
+// DeleteDeadWorkers mocks base method.
+func (m *MockQuerier) DeleteDeadWorkers(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDeadWorkers", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteDeadWorkers indicates an expected call of DeleteDeadWorkers.
+func (mr *MockQuerierMockRecorder) DeleteDeadWorkers(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeadWorkers", reflect.TypeOf((*MockQuerier)(nil).DeleteDeadWorkers), ctx)
+}
+
 // DeleteGitHubRepoInfo mocks base method.
 func (m *MockQuerier) DeleteGitHubRepoInfo(ctx context.Context, repoID uuid.UUID) error {
 	m.ctrl.T.Helper()
@@ -95,6 +127,21 @@ func (mr *MockQuerierMockRecorder) DeleteGitHubRepoInfo(ctx, repoID interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGitHubRepoInfo", reflect.TypeOf((*MockQuerier)(nil).DeleteGitHubRepoInfo), ctx, repoID)
 }
 
+// DeleteOrphanedGitBlobs mocks base method.
+func (m *MockQuerier) DeleteOrphanedGitBlobs(ctx context.Context, lim int32) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOrphanedGitBlobs", ctx, lim)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteOrphanedGitBlobs indicates an expected call of DeleteOrphanedGitBlobs.
+func (mr *MockQuerierMockRecorder) DeleteOrphanedGitBlobs(ctx, lim interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrphanedGitBlobs", reflect.TypeOf((*MockQuerier)(nil).DeleteOrphanedGitBlobs), ctx, lim)
+}
+
 // DeleteRemovedRepos mocks base method.
 func (m *MockQuerier) DeleteRemovedRepos(ctx context.Context, arg db.DeleteRemovedReposParams) error {
 	m.ctrl.T.Helper()
@@ -109,19 +156,47 @@ func (mr *MockQuerierMockRecorder) DeleteRemovedRepos(ctx, arg interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRemovedRepos", reflect.TypeOf((*MockQuerier)(nil).DeleteRemovedRepos), ctx, arg)
 }
 
+// DeleteRepoSyncCheckpoints mocks base method.
+func (m *MockQuerier) DeleteRepoSyncCheckpoints(ctx context.Context, repoSyncID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRepoSyncCheckpoints", ctx, repoSyncID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRepoSyncCheckpoints indicates an expected call of DeleteRepoSyncCheckpoints.
+func (mr *MockQuerierMockRecorder) DeleteRepoSyncCheckpoints(ctx, repoSyncID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRepoSyncCheckpoints", reflect.TypeOf((*MockQuerier)(nil).DeleteRepoSyncCheckpoints), ctx, repoSyncID)
+}
+
+// DeleteWorker mocks base method.
+func (m *MockQuerier) DeleteWorker(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWorker", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWorker indicates an expected call of DeleteWorker.
+func (mr *MockQuerierMockRecorder) DeleteWorker(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWorker", reflect.TypeOf((*MockQuerier)(nil).DeleteWorker), ctx, id)
+}
+
 // DequeueSyncJob mocks base method.
-func (m *MockQuerier) DequeueSyncJob(ctx context.Context) (db.DequeueSyncJobRow, error) {
+func (m *MockQuerier) DequeueSyncJob(ctx context.Context, arg db.DequeueSyncJobParams) (db.DequeueSyncJobRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DequeueSyncJob", ctx)
+	ret := m.ctrl.Call(m, "DequeueSyncJob", ctx, arg)
 	ret0, _ := ret[0].(db.DequeueSyncJobRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // DequeueSyncJob indicates an expected call of DequeueSyncJob.
-func (mr *MockQuerierMockRecorder) DequeueSyncJob(ctx interface{}) *gomock.Call {
+func (mr *MockQuerierMockRecorder) DequeueSyncJob(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DequeueSyncJob", reflect.TypeOf((*MockQuerier)(nil).DequeueSyncJob), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DequeueSyncJob", reflect.TypeOf((*MockQuerier)(nil).DequeueSyncJob), ctx, arg)
 }
 
 // EnableContainerSync mocks base method.
@@ -139,17 +214,47 @@ func (mr *MockQuerierMockRecorder) EnableContainerSync(ctx, arg interface{}) *go
 }
 
 // EnqueueAllSyncs mocks base method.
-func (m *MockQuerier) EnqueueAllSyncs(ctx context.Context) error {
+func (m *MockQuerier) EnqueueAllSyncs(ctx context.Context, dueSyncIds []uuid.UUID) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "EnqueueAllSyncs", ctx)
+	ret := m.ctrl.Call(m, "EnqueueAllSyncs", ctx, dueSyncIds)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // EnqueueAllSyncs indicates an expected call of EnqueueAllSyncs.
-func (mr *MockQuerierMockRecorder) EnqueueAllSyncs(ctx interface{}) *gomock.Call {
+func (mr *MockQuerierMockRecorder) EnqueueAllSyncs(ctx, dueSyncIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueAllSyncs", reflect.TypeOf((*MockQuerier)(nil).EnqueueAllSyncs), ctx, dueSyncIds)
+}
+
+// EnqueueStrandedSyncJobs mocks base method.
+func (m *MockQuerier) EnqueueStrandedSyncJobs(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueueStrandedSyncJobs", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnqueueStrandedSyncJobs indicates an expected call of EnqueueStrandedSyncJobs.
+func (mr *MockQuerierMockRecorder) EnqueueStrandedSyncJobs(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueAllSyncs", reflect.TypeOf((*MockQuerier)(nil).EnqueueAllSyncs), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueStrandedSyncJobs", reflect.TypeOf((*MockQuerier)(nil).EnqueueStrandedSyncJobs), ctx)
+}
+
+// FailUnrunnableSyncJobs mocks base method.
+func (m *MockQuerier) FailUnrunnableSyncJobs(ctx context.Context) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailUnrunnableSyncJobs", ctx)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FailUnrunnableSyncJobs indicates an expected call of FailUnrunnableSyncJobs.
+func (mr *MockQuerierMockRecorder) FailUnrunnableSyncJobs(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailUnrunnableSyncJobs", reflect.TypeOf((*MockQuerier)(nil).FailUnrunnableSyncJobs), ctx)
 }
 
 // FetchContainerSync mocks base method.
@@ -242,6 +347,21 @@ func (mr *MockQuerierMockRecorder) GetRepoImportByID(ctx, id interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRepoImportByID", reflect.TypeOf((*MockQuerier)(nil).GetRepoImportByID), ctx, id)
 }
 
+// GetRepoSyncTypeSettingsSchema mocks base method.
+func (m *MockQuerier) GetRepoSyncTypeSettingsSchema(ctx context.Context, type_ string) (pgtype.JSONB, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRepoSyncTypeSettingsSchema", ctx, type_)
+	ret0, _ := ret[0].(pgtype.JSONB)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRepoSyncTypeSettingsSchema indicates an expected call of GetRepoSyncTypeSettingsSchema.
+func (mr *MockQuerierMockRecorder) GetRepoSyncTypeSettingsSchema(ctx, type_ interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRepoSyncTypeSettingsSchema", reflect.TypeOf((*MockQuerier)(nil).GetRepoSyncTypeSettingsSchema), ctx, type_)
+}
+
 // GetRepoUrlFromImport mocks base method.
 func (m *MockQuerier) GetRepoUrlFromImport(ctx context.Context, importid uuid.UUID) ([]string, error) {
 	m.ctrl.T.Helper()
@@ -257,6 +377,21 @@ func (mr *MockQuerierMockRecorder) GetRepoUrlFromImport(ctx, importid interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRepoUrlFromImport", reflect.TypeOf((*MockQuerier)(nil).GetRepoUrlFromImport), ctx, importid)
 }
 
+// GetSyncJobRetryPolicy mocks base method.
+func (m *MockQuerier) GetSyncJobRetryPolicy(ctx context.Context, id int64) (db.GetSyncJobRetryPolicyRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSyncJobRetryPolicy", ctx, id)
+	ret0, _ := ret[0].(db.GetSyncJobRetryPolicyRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSyncJobRetryPolicy indicates an expected call of GetSyncJobRetryPolicy.
+func (mr *MockQuerierMockRecorder) GetSyncJobRetryPolicy(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSyncJobRetryPolicy", reflect.TypeOf((*MockQuerier)(nil).GetSyncJobRetryPolicy), ctx, id)
+}
+
 // InsertGitHubRepoInfo mocks base method.
 func (m *MockQuerier) InsertGitHubRepoInfo(ctx context.Context, arg db.InsertGitHubRepoInfoParams) error {
 	m.ctrl.T.Helper()
@@ -299,6 +434,21 @@ func (mr *MockQuerierMockRecorder) InsertSyncJobLog(ctx, arg interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertSyncJobLog", reflect.TypeOf((*MockQuerier)(nil).InsertSyncJobLog), ctx, arg)
 }
 
+// ListEnabledSyncTypes mocks base method.
+func (m *MockQuerier) ListEnabledSyncTypes(ctx context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEnabledSyncTypes", ctx)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEnabledSyncTypes indicates an expected call of ListEnabledSyncTypes.
+func (mr *MockQuerierMockRecorder) ListEnabledSyncTypes(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEnabledSyncTypes", reflect.TypeOf((*MockQuerier)(nil).ListEnabledSyncTypes), ctx)
+}
+
 // ListRepoImportsDueForImport mocks base method.
 func (m *MockQuerier) ListRepoImportsDueForImport(ctx context.Context) ([]db.ListRepoImportsDueForImportRow, error) {
 	m.ctrl.T.Helper()
@@ -314,6 +464,36 @@ func (mr *MockQuerierMockRecorder) ListRepoImportsDueForImport(ctx interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRepoImportsDueForImport", reflect.TypeOf((*MockQuerier)(nil).ListRepoImportsDueForImport), ctx)
 }
 
+// ListRepoSyncCheckpoints mocks base method.
+func (m *MockQuerier) ListRepoSyncCheckpoints(ctx context.Context, repoSyncID uuid.UUID) ([]db.MergestatRepoSyncCheckpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRepoSyncCheckpoints", ctx, repoSyncID)
+	ret0, _ := ret[0].([]db.MergestatRepoSyncCheckpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRepoSyncCheckpoints indicates an expected call of ListRepoSyncCheckpoints.
+func (mr *MockQuerierMockRecorder) ListRepoSyncCheckpoints(ctx, repoSyncID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRepoSyncCheckpoints", reflect.TypeOf((*MockQuerier)(nil).ListRepoSyncCheckpoints), ctx, repoSyncID)
+}
+
+// ListSyncSchedules mocks base method.
+func (m *MockQuerier) ListSyncSchedules(ctx context.Context) ([]db.ListSyncSchedulesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSyncSchedules", ctx)
+	ret0, _ := ret[0].([]db.ListSyncSchedulesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSyncSchedules indicates an expected call of ListSyncSchedules.
+func (mr *MockQuerierMockRecorder) ListSyncSchedules(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSyncSchedules", reflect.TypeOf((*MockQuerier)(nil).ListSyncSchedules), ctx)
+}
+
 // MarkRepoImportAsUpdated mocks base method.
 func (m *MockQuerier) MarkRepoImportAsUpdated(ctx context.Context, id uuid.UUID) error {
 	m.ctrl.T.Helper()
@@ -343,14 +523,89 @@ func (mr *MockQuerierMockRecorder) MarkSyncsAsTimedOut(ctx interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkSyncsAsTimedOut", reflect.TypeOf((*MockQuerier)(nil).MarkSyncsAsTimedOut), ctx)
 }
 
-// SetLatestKeepAliveForJob mocks base method.
-func (m *MockQuerier) SetLatestKeepAliveForJob(ctx context.Context, id int64) error {
+// RegisterWorker mocks base method.
+func (m *MockQuerier) RegisterWorker(ctx context.Context, arg db.RegisterWorkerParams) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetLatestKeepAliveForJob", ctx, id)
+	ret := m.ctrl.Call(m, "RegisterWorker", ctx, arg)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// RegisterWorker indicates an expected call of RegisterWorker.
+func (mr *MockQuerierMockRecorder) RegisterWorker(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterWorker", reflect.TypeOf((*MockQuerier)(nil).RegisterWorker), ctx, arg)
+}
+
+// RequeueOrphanedSyncJobs mocks base method.
+func (m *MockQuerier) RequeueOrphanedSyncJobs(ctx context.Context) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequeueOrphanedSyncJobs", ctx)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequeueOrphanedSyncJobs indicates an expected call of RequeueOrphanedSyncJobs.
+func (mr *MockQuerierMockRecorder) RequeueOrphanedSyncJobs(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequeueOrphanedSyncJobs", reflect.TypeOf((*MockQuerier)(nil).RequeueOrphanedSyncJobs), ctx)
+}
+
+// RequeueSyncJob mocks base method.
+func (m *MockQuerier) RequeueSyncJob(ctx context.Context, id int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequeueSyncJob", ctx, id)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequeueSyncJob indicates an expected call of RequeueSyncJob.
+func (mr *MockQuerierMockRecorder) RequeueSyncJob(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequeueSyncJob", reflect.TypeOf((*MockQuerier)(nil).RequeueSyncJob), ctx, id)
+}
+
+// RetrySyncJob mocks base method.
+func (m *MockQuerier) RetrySyncJob(ctx context.Context, id int64) (sql.NullTime, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetrySyncJob", ctx, id)
+	ret0, _ := ret[0].(sql.NullTime)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetrySyncJob indicates an expected call of RetrySyncJob.
+func (mr *MockQuerierMockRecorder) RetrySyncJob(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetrySyncJob", reflect.TypeOf((*MockQuerier)(nil).RetrySyncJob), ctx, id)
+}
+
+// SendWorkerHeartbeat mocks base method.
+func (m *MockQuerier) SendWorkerHeartbeat(ctx context.Context, id uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendWorkerHeartbeat", ctx, id)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SendWorkerHeartbeat indicates an expected call of SendWorkerHeartbeat.
+func (mr *MockQuerierMockRecorder) SendWorkerHeartbeat(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendWorkerHeartbeat", reflect.TypeOf((*MockQuerier)(nil).SendWorkerHeartbeat), ctx, id)
+}
+
+// SetLatestKeepAliveForJob mocks base method.
+func (m *MockQuerier) SetLatestKeepAliveForJob(ctx context.Context, id int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLatestKeepAliveForJob", ctx, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // SetLatestKeepAliveForJob indicates an expected call of SetLatestKeepAliveForJob.
 func (mr *MockQuerierMockRecorder) SetLatestKeepAliveForJob(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
@@ -399,6 +654,20 @@ func (mr *MockQuerierMockRecorder) UpsertRepo(ctx, arg interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertRepo", reflect.TypeOf((*MockQuerier)(nil).UpsertRepo), ctx, arg)
 }
 
+// UpsertRepoSyncCheckpoint mocks base method.
+func (m *MockQuerier) UpsertRepoSyncCheckpoint(ctx context.Context, arg db.UpsertRepoSyncCheckpointParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertRepoSyncCheckpoint", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertRepoSyncCheckpoint indicates an expected call of UpsertRepoSyncCheckpoint.
+func (mr *MockQuerierMockRecorder) UpsertRepoSyncCheckpoint(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertRepoSyncCheckpoint", reflect.TypeOf((*MockQuerier)(nil).UpsertRepoSyncCheckpoint), ctx, arg)
+}
+
 // UpsertWorkflowRunJobs mocks base method.
 func (m *MockQuerier) UpsertWorkflowRunJobs(ctx context.Context, arg db.UpsertWorkflowRunJobsParams) error {
 	m.ctrl.T.Helper()
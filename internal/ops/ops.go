@@ -0,0 +1,163 @@
+// Package ops implements the operational HTTP server of the worker, serving its health, readiness and metrics
+package ops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mergestat/mergestat/internal/db"
+	"github.com/mergestat/mergestat/pkg/registry"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog"
+)
+
+type server struct {
+	logger           *zerolog.Logger
+	pool             *pgxpool.Pool
+	db               *db.Queries
+	migrationVersion uint
+}
+
+// New returns the handler of the ops server. migrationVersion is the version of the schema the worker migrated
+// the database to on startup, and requires to be ready. If debug is set, pprof is served under /debug/pprof/.
+func New(logger *zerolog.Logger, pool *pgxpool.Pool, migrationVersion uint, debug bool) http.Handler {
+	var s = &server{logger: logger, pool: pool, db: db.New(pool), migrationVersion: migrationVersion}
+
+	var mux = http.NewServeMux()
+	mux.HandleFunc("/healthz", s.healthz)
+	mux.HandleFunc("/readyz", s.readyz)
+	mux.Handle("/metrics", promhttp.Handler())
+
+	if debug {
+		// net/http/pprof registers its handlers with the default mux
+		mux.Handle("/debug/pprof/", http.DefaultServeMux)
+	}
+
+	return mux
+}
+
+// Serve runs the ops server on the given address until the context is canceled
+func Serve(ctx context.Context, logger *zerolog.Logger, addr string, handler http.Handler) {
+	var srv = &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := srv.Shutdown(ctx); err != nil {
+			logger.Err(err).Msg("could not shutdown ops HTTP server")
+		}
+	}()
+
+	logger.Info().Msgf("serving health, readiness and metrics on %s", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Err(err).Msgf("could not start ops HTTP server")
+	}
+}
+
+// healthz reports that the process is up
+func (s *server) healthz(w http.ResponseWriter, _ *http.Request) {
+	_, _ = fmt.Fprintln(w, "ok")
+}
+
+// readyz reports whether the worker is ready to process syncs: the database is reachable and migrated, and the
+// executables required by the enabled sync types the worker is configured to run (with WORKER_SYNC_TYPES) are installed.
+// Without that configuration, sync types whose executables are missing are left to other workers (see syncer.probe).
+func (s *server) readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var checks = []struct {
+		name  string
+		check func(context.Context) error
+	}{
+		{"postgres", s.pool.Ping},
+		{"migrations", s.checkMigrations},
+		{"executables", s.checkExecutables},
+	}
+
+	var failed bool
+	var report strings.Builder
+	for _, c := range checks {
+		if err := c.check(ctx); err != nil {
+			failed = true
+			fmt.Fprintf(&report, "%s: %v\n", c.name, err)
+		} else {
+			fmt.Fprintf(&report, "%s: ok\n", c.name)
+		}
+	}
+
+	if failed {
+		s.logger.Warn().Msgf("readiness check failed: %s", report.String())
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	_, _ = w.Write([]byte(report.String()))
+}
+
+func (s *server) checkMigrations(ctx context.Context) error {
+	var version int64
+	var dirty bool
+	if err := s.pool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty); err != nil {
+		return err
+	}
+
+	if dirty {
+		return fmt.Errorf("migration %d failed and must be fixed manually", version)
+	}
+
+	if version < int64(s.migrationVersion) {
+		return fmt.Errorf("database is at version %d, expected at least %d", version, s.migrationVersion)
+	}
+
+	return nil
+}
+
+func (s *server) checkExecutables(ctx context.Context) error {
+	var types, err = s.db.ListEnabledSyncTypes(ctx)
+	if err != nil {
+		return err
+	}
+
+	var configured = make(map[string]bool)
+	for _, typ := range strings.Split(os.Getenv("WORKER_SYNC_TYPES"), ",") {
+		configured[strings.TrimSpace(typ)] = true
+	}
+
+	var missing = make(map[string][]string) // executable -> sync types requiring it
+	for _, typ := range types {
+		var reg, ok = registry.Lookup(typ)
+		if !ok || !configured[typ] {
+			continue
+		}
+
+		for _, capability := range reg.Capabilities {
+			if name, ok := capability.Executable(); ok {
+				if _, err := exec.LookPath(name); err != nil {
+					missing[name] = append(missing[name], typ)
+				}
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		var names = make([]string, 0, len(missing))
+		for name, types := range missing {
+			names = append(names, fmt.Sprintf("%s (required by %s)", name, strings.Join(types, ", ")))
+		}
+		sort.Strings(names)
+		return fmt.Errorf("missing from PATH: %s", strings.Join(names, "; "))
+	}
+
+	return nil
+}
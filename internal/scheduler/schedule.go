@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mergestat/mergestat/internal/db"
+	"github.com/mergestat/mergestat/internal/helper"
+	"github.com/robfig/cron/v3"
+)
+
+// dueSyncs returns the IDs of the enabled syncs that are due to run at the given time, according to their schedule.
+// A sync is due if the next time matching its cron expression after it was last enqueued has passed, and its
+// minimum interval since then has elapsed. Syncs without a schedule are due on every cycle.
+func (s *scheduler) dueSyncs(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	var syncs, err = s.db.ListSyncSchedules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var due = make([]uuid.UUID, 0, len(syncs))
+	for _, sync := range syncs {
+		if s.isDue(&sync, now) {
+			due = append(due, sync.ID)
+		}
+	}
+
+	return due, nil
+}
+
+func (s *scheduler) isDue(sync *db.ListSyncSchedulesRow, now time.Time) bool {
+	if !sync.LastEnqueuedAt.Valid {
+		return true // never ran before
+	}
+	var last = sync.LastEnqueuedAt.Time
+
+	if interval, ok := helper.IntervalToDuration(sync.MinInterval); ok && now.Before(last.Add(interval)) {
+		return false
+	}
+
+	if sync.Schedule.Valid && sync.Schedule.String != "" {
+		var schedule, err = s.parseSchedule(sync.Schedule.String)
+		if err != nil {
+			s.logger.Warn().AnErr("error", err).Msgf("invalid schedule %q for %s sync %s, skipping it", sync.Schedule.String, sync.SyncType, sync.ID)
+			return false
+		}
+
+		if now.Before(schedule.Next(last)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseSchedule parses a standard cron expression (or a descriptor like @daily), caching the result
+func (s *scheduler) parseSchedule(spec string) (cron.Schedule, error) {
+	if schedule, ok := s.schedules[spec]; ok {
+		return schedule, nil
+	}
+
+	var schedule, err = cron.ParseStandard(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	s.schedules[spec] = schedule
+	return schedule, nil
+}
@@ -0,0 +1,201 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/mergestat/mergestat/internal/db"
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog"
+)
+
+func newTestScheduler(dbtx db.DBTX) *scheduler {
+	var logger = zerolog.Nop()
+	return &scheduler{logger: &logger, db: db.New(dbtx), schedules: make(map[string]cron.Schedule)}
+}
+
+func interval(d time.Duration) pgtype.Interval {
+	return pgtype.Interval{Microseconds: d.Microseconds(), Status: pgtype.Present}
+}
+
+func TestIsDue(t *testing.T) {
+	type testArgs struct {
+		description string
+		schedule    string
+		minInterval pgtype.Interval
+		last        time.Time
+		now         time.Time
+		want        bool
+	}
+
+	var at = func(value string) time.Time {
+		var t, _ = time.Parse(time.RFC3339, value)
+		return t
+	}
+
+	tests := []testArgs{
+		{
+			description: "first run without a schedule",
+			now:         at("2023-01-01T00:00:00Z"),
+			want:        true,
+		},
+		{
+			description: "first run with a schedule",
+			schedule:    "0 3 * * *",
+			minInterval: interval(time.Hour),
+			now:         at("2023-01-01T00:00:00Z"),
+			want:        true,
+		},
+		{
+			description: "no schedule and no minimum interval",
+			last:        at("2023-01-01T00:00:00Z"),
+			now:         at("2023-01-01T00:00:01Z"),
+			want:        true,
+		},
+		{
+			description: "minimum interval not elapsed",
+			minInterval: interval(time.Hour),
+			last:        at("2023-01-01T00:00:00Z"),
+			now:         at("2023-01-01T00:59:59Z"),
+			want:        false,
+		},
+		{
+			description: "minimum interval elapsed",
+			minInterval: interval(time.Hour),
+			last:        at("2023-01-01T00:00:00Z"),
+			now:         at("2023-01-01T01:00:00Z"),
+			want:        true,
+		},
+		{
+			description: "cron expression not matched yet",
+			schedule:    "0 3 * * *",
+			last:        at("2023-01-01T03:00:00Z"),
+			now:         at("2023-01-02T02:59:00Z"),
+			want:        false,
+		},
+		{
+			description: "cron expression matched since last run",
+			schedule:    "0 3 * * *",
+			last:        at("2023-01-01T03:00:00Z"),
+			now:         at("2023-01-02T03:00:00Z"),
+			want:        true,
+		},
+		{
+			description: "cron expression matched but minimum interval not elapsed",
+			schedule:    "@hourly",
+			minInterval: interval(6 * time.Hour),
+			last:        at("2023-01-01T00:00:00Z"),
+			now:         at("2023-01-01T05:00:00Z"),
+			want:        false,
+		},
+		{
+			description: "minimum interval elapsed but cron expression not matched yet",
+			schedule:    "@daily",
+			minInterval: interval(time.Hour),
+			last:        at("2023-01-01T00:00:00Z"),
+			now:         at("2023-01-01T12:00:00Z"),
+			want:        false,
+		},
+		{
+			description: "cron expression in another timezone not matched yet",
+			schedule:    "CRON_TZ=America/New_York 0 3 * * *",
+			last:        at("2023-01-01T08:00:00Z"),
+			now:         at("2023-01-02T03:00:00Z"),
+			want:        false,
+		},
+		{
+			description: "cron expression in another timezone matched",
+			schedule:    "CRON_TZ=America/New_York 0 3 * * *",
+			last:        at("2023-01-01T08:00:00Z"),
+			now:         at("2023-01-02T08:00:00Z"),
+			want:        true,
+		},
+		{
+			description: "invalid cron expression",
+			schedule:    "every day",
+			last:        at("2023-01-01T00:00:00Z"),
+			now:         at("2023-02-01T00:00:00Z"),
+			want:        false,
+		},
+	}
+
+	var s = newTestScheduler(nil)
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			var sync = db.ListSyncSchedulesRow{
+				ID:             uuid.New(),
+				SyncType:       "GIT_COMMITS",
+				Schedule:       sql.NullString{String: test.schedule, Valid: test.schedule != ""},
+				MinInterval:    test.minInterval,
+				LastEnqueuedAt: sql.NullTime{Time: test.last, Valid: !test.last.IsZero()},
+			}
+
+			if got := s.isDue(&sync, test.now); got != test.want {
+				t.Fatalf("isDue() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// fakeRows implements the subset of pgx.Rows used by the generated queries
+type fakeRows struct {
+	pgx.Rows
+	rows [][]interface{}
+	next int
+}
+
+func (r *fakeRows) Close()     {}
+func (r *fakeRows) Err() error { return nil }
+func (r *fakeRows) Next() bool { r.next++; return r.next <= len(r.rows) }
+
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	for i, value := range r.rows[r.next-1] {
+		reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(value))
+	}
+	return nil
+}
+
+// fakeDB returns the given rows for any query
+type fakeDB struct {
+	db.DBTX
+	rows [][]interface{}
+}
+
+func (f *fakeDB) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return &fakeRows{rows: f.rows}, nil
+}
+
+func TestDueSyncs(t *testing.T) {
+	var now = time.Date(2023, 1, 2, 12, 0, 0, 0, time.UTC)
+	var row = func(id uuid.UUID, schedule string, last time.Time) []interface{} {
+		return []interface{}{
+			id, "GIT_COMMITS",
+			sql.NullString{String: schedule, Valid: schedule != ""},
+			pgtype.Interval{Status: pgtype.Null},
+			sql.NullTime{Time: last, Valid: !last.IsZero()},
+		}
+	}
+
+	var neverRan, unscheduled, due, notDue = uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	var s = newTestScheduler(&fakeDB{rows: [][]interface{}{
+		row(neverRan, "@daily", time.Time{}),
+		row(unscheduled, "", now.Add(-time.Minute)),
+		row(due, "@hourly", now.Add(-time.Hour)),
+		row(notDue, "@daily", now.Add(-time.Hour)),
+	}})
+
+	got, err := s.dueSyncs(context.Background(), now)
+	if err != nil {
+		t.Fatalf("dueSyncs() returned error: %v", err)
+	}
+
+	if want := []uuid.UUID{neverRan, unscheduled, due}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("dueSyncs() = %v, want %v", got, want)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/mergestat/mergestat/internal/db"
+	"github.com/robfig/cron/v3"
 	"github.com/rs/zerolog"
 )
 
@@ -15,6 +16,9 @@ type scheduler struct {
 	logger *zerolog.Logger
 	pool   *pgxpool.Pool
 	db     *db.Queries
+
+	// parsed cron expressions of the syncs' schedules, by expression
+	schedules map[string]cron.Schedule
 }
 
 func New(logger *zerolog.Logger, pool *pgxpool.Pool) *scheduler {
@@ -22,16 +26,20 @@ func New(logger *zerolog.Logger, pool *pgxpool.Pool) *scheduler {
 		logger: logger,
 		pool:   pool,
 		db:     db.New(pool),
+
+		schedules: make(map[string]cron.Schedule),
 	}
 }
 
 func (s *scheduler) Start(ctx context.Context, interval time.Duration) {
 	s.logger.Info().Msg("starting scheduler")
 	exec := func() {
-		if err := s.db.EnqueueAllSyncs(ctx); err != nil {
+		if due, err := s.dueSyncs(ctx, time.Now()); err != nil {
+			s.logger.Err(err).Msg("encountered error listing the syncs due to run")
+		} else if err := s.db.EnqueueAllSyncs(ctx, due); err != nil {
 			s.logger.Err(err).Msg("encountered error during scheduler execution")
 		} else {
-			s.logger.Info().Msg("re-scheduling all completed syncs to run again")
+			s.logger.Info().Msgf("re-scheduling completed syncs due to run again: %d due", len(due))
 		}
 
 		// TODO(patrickdevivo) this should probably be lifted up into a config/param
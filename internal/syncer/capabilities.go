@@ -0,0 +1,163 @@
+package syncer
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"os/exec"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mergestat/mergestat/internal/db"
+	"github.com/mergestat/mergestat/pkg/registry"
+	"github.com/rs/zerolog"
+)
+
+// heartbeatInterval is how often the worker reports it's alive. Workers that missed heartbeats for a while are
+// removed by the timeout routine (see DeleteDeadWorkers).
+const heartbeatInterval = 15 * time.Second
+
+// env vars restricting the sync types run by the worker, as comma separated lists of sync types
+const (
+	envAllowedSyncTypes  = "WORKER_SYNC_TYPES"          // if set, only these sync types are run
+	envExcludedSyncTypes = "WORKER_EXCLUDED_SYNC_TYPES" // these sync types are never run
+)
+
+// splitSyncTypes parses a comma separated list of sync types
+func splitSyncTypes(list string) []string {
+	var types []string
+	for _, typ := range strings.Split(list, ",") {
+		if typ = strings.TrimSpace(typ); typ != "" {
+			types = append(types, typ)
+		}
+	}
+	return types
+}
+
+// probe returns the capabilities available to the worker, and the sync types it can run: the registered ones
+// whose required executables are installed, and that are allowed by the worker's env (see envAllowedSyncTypes).
+func probe(logger *zerolog.Logger) (capabilities []string, syncTypes []string) {
+	var allowed, excluded = splitSyncTypes(os.Getenv(envAllowedSyncTypes)), splitSyncTypes(os.Getenv(envExcludedSyncTypes))
+
+	var available = make(map[registry.Capability]bool)
+	for _, name := range registry.Names() {
+		var reg, _ = registry.Lookup(name)
+
+		var runnable = true
+		for _, capability := range reg.Capabilities {
+			if _, probed := available[capability]; !probed {
+				available[capability] = true
+				if executable, ok := capability.Executable(); ok {
+					if _, err := exec.LookPath(executable); err != nil {
+						logger.Warn().Msgf("%s is not installed, syncs requiring it won't be run by this worker", executable)
+						available[capability] = false
+					}
+				}
+			}
+			runnable = runnable && available[capability]
+		}
+
+		switch {
+		case !runnable:
+		case len(allowed) > 0 && !contains(allowed, name):
+		case contains(excluded, name):
+		default:
+			syncTypes = append(syncTypes, name)
+		}
+	}
+
+	for capability, ok := range available {
+		if ok {
+			capabilities = append(capabilities, string(capability))
+		}
+	}
+	sort.Strings(capabilities)
+
+	return capabilities, syncTypes
+}
+
+// register advertises the capabilities of the worker and the sync types it can run in mergestat.workers,
+// retrying until it succeeds or the context is canceled
+func (w *worker) register(ctx context.Context) error {
+	var hostname, _ = os.Hostname()
+
+	var params = db.RegisterWorkerParams{
+		ID:           w.id,
+		Hostname:     hostname,
+		Version:      version(),
+		Concurrency:  int32(w.concurrency),
+		Capabilities: w.capabilities,
+		SyncTypes:    w.syncTypes,
+	}
+	for {
+		var err = w.db.RegisterWorker(ctx, params)
+		if err == nil {
+			w.logger.Info().Msgf("registered worker %s, running sync types: %s", w.id, strings.Join(w.syncTypes, ", "))
+			return nil
+		}
+
+		w.logger.Err(err).Msgf("could not register worker, retrying: %v", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
+
+// heartbeat reports that the worker is alive every heartbeatInterval until the context is canceled, registering
+// it again if it was removed in the meantime (e.g. after the database was unreachable for a while)
+func (w *worker) heartbeat(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(heartbeatInterval):
+		}
+
+		if n, err := w.db.SendWorkerHeartbeat(ctx, w.id); err != nil {
+			w.logger.Err(err).Msgf("could not send worker heartbeat: %v", err)
+		} else if n == 0 {
+			w.logger.Warn().Msgf("worker %s was removed, registering it again", w.id)
+			_ = w.register(ctx)
+		}
+	}
+}
+
+// unregister removes the worker from mergestat.workers when it stops
+func (w *worker) unregister() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := w.db.DeleteWorker(ctx, w.id); err != nil {
+		w.logger.Err(err).Msgf("could not unregister worker: %v", err)
+	}
+}
+
+// version returns the version of the worker binary, as recorded in its build info
+func version() sql.NullString {
+	var info, ok = debug.ReadBuildInfo()
+	if !ok {
+		return sql.NullString{}
+	}
+
+	var v = info.Main.Version
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			v += " (" + setting.Value + ")"
+		}
+	}
+
+	return sql.NullString{String: v, Valid: v != ""}
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
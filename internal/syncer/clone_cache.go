@@ -0,0 +1,264 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// cloneCacheDirPrefix is the prefix of the per-repo directories in the clone cache.
+// The suffix is the ID of the repository.
+const cloneCacheDirPrefix = "mergestat-repo-"
+
+// worktreeDirPrefix is the prefix of the private working trees created from the clones in the cache (see worker.checkoutWorktree).
+// It's followed by the ID of the worker owning the working tree.
+const worktreeDirPrefix = "mergestat-worktree-"
+
+// lockFileSuffix is the suffix of the lock files of the clones in the cache, next to their directory (see cloneCacheEntry.lock).
+const lockFileSuffix = ".lock"
+
+// cloneCache keeps persistent, on-disk clones of repositories under a base path, keyed by repo ID,
+// so that all git syncs of a repo share a single clone instead of cloning the repo on every run.
+//
+// Updates of a clone (clone or fetch) are serialized by a per-repo file lock, which is only held for the duration of
+// the update, so that a long running sync doesn't hold up the updates of other syncs. Being a file lock, it's also
+// held against the other workers sharing the base path (e.g. replicas mounting the same volume). Syncs only read the objects
+// and refs of the clone, which remain readable while it's updated. Once the total size of the cache exceeds maxSize,
+// the least recently used clones that are not in use are evicted.
+type cloneCache struct {
+	logger   *zerolog.Logger
+	basePath string
+	maxSize  int64 // in bytes, a value <= 0 disables eviction
+
+	mu      sync.Mutex // guards entries and the size, refs and lastUsed fields of each entry
+	entries map[uuid.UUID]*cloneCacheEntry
+}
+
+type cloneCacheEntry struct {
+	path     string
+	lockFile *os.File // held while the clone is updated, see lock and unlock
+
+	size     int64     // size of the clone on disk, in bytes
+	refs     int       // number of active users of the clone
+	lastUsed time.Time // time the clone was last released
+}
+
+func newCloneCacheEntry(path string) *cloneCacheEntry {
+	return &cloneCacheEntry{path: path}
+}
+
+// lock acquires the lock of the entry, waiting for it until the ctx is done. The lock is a file lock on
+// a file next to the clone (see flock(2)), polled for as a blocking flock can't be interrupted.
+func (e *cloneCacheEntry) lock(ctx context.Context) error {
+	for {
+		var f, err = tryLock(e.path + lockFileSuffix)
+		if err != nil {
+			return err
+		} else if f != nil {
+			e.lockFile = f
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// unlock releases the lock of the entry acquired by lock.
+func (e *cloneCacheEntry) unlock() {
+	var f = e.lockFile
+	e.lockFile = nil
+	_ = f.Close() // closing the file releases the lock
+}
+
+// tryLock acquires an exclusive file lock on the file (or directory) at path, creating the file if it doesn't exist,
+// without waiting for it. It returns the open file holding the lock, released once closed, or nil if the lock is
+// held by someone else.
+func tryLock(path string) (*os.File, error) {
+	var f, err = os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0o644)
+	if err != nil {
+		// directories can't be opened with O_CREATE
+		if !errors.Is(err, syscall.EISDIR) {
+			return nil, err
+		}
+		if f, err = os.Open(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// newCloneCache creates a new cloneCache rooted at basePath, picking up any clones left on disk by a previous run and
+// removing the working trees left behind by workers that are gone. The working tree of a worker is locked for as long
+// as it exists (see worker.checkoutWorktree), so the ones in use by the other workers sharing basePath are left alone.
+// If basePath is empty, the default temp directory is used.
+func newCloneCache(logger *zerolog.Logger, basePath string, maxSize int64) *cloneCache {
+	if basePath == "" {
+		basePath = os.TempDir()
+	}
+
+	var c = &cloneCache{
+		logger:   logger,
+		basePath: basePath,
+		maxSize:  maxSize,
+		entries:  make(map[uuid.UUID]*cloneCacheEntry),
+	}
+
+	var dirs, err = os.ReadDir(basePath)
+	if err != nil {
+		logger.Warn().AnErr("error", err).Msgf("could not read clone cache directory: %s", basePath)
+		return c
+	}
+
+	for _, dir := range dirs {
+		if dir.IsDir() && strings.HasPrefix(dir.Name(), worktreeDirPrefix) {
+			var path = filepath.Join(basePath, dir.Name())
+			if f, err := tryLock(path); err != nil {
+				logger.Warn().AnErr("error", err).Msgf("could not lock leftover working tree: %s", dir.Name())
+			} else if f != nil {
+				if err := os.RemoveAll(path); err != nil {
+					logger.Warn().AnErr("error", err).Msgf("could not remove leftover working tree: %s", dir.Name())
+				}
+				_ = f.Close()
+			}
+			continue
+		}
+
+		if !dir.IsDir() || !strings.HasPrefix(dir.Name(), cloneCacheDirPrefix) {
+			continue
+		}
+
+		// skip directories that are not managed by the cache (like temp dirs from older versions)
+		var id uuid.UUID
+		if id, err = uuid.Parse(strings.TrimPrefix(dir.Name(), cloneCacheDirPrefix)); err != nil {
+			continue
+		}
+
+		var entry = newCloneCacheEntry(filepath.Join(basePath, dir.Name()))
+		entry.size = dirSize(entry.path)
+		if info, err := dir.Info(); err == nil {
+			entry.lastUsed = info.ModTime()
+		}
+		c.entries[id] = entry
+	}
+
+	return c
+}
+
+// acquire returns the cache entry for the given repo, creating it if needed, and marks it as in use.
+// Callers must call release once they are done with the entry.
+func (c *cloneCache) acquire(repoID uuid.UUID) *cloneCacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var entry, ok = c.entries[repoID]
+	if !ok {
+		entry = newCloneCacheEntry(filepath.Join(c.basePath, cloneCacheDirPrefix+repoID.String()))
+		c.entries[repoID] = entry
+	}
+	entry.refs++
+
+	return entry
+}
+
+// updated records the new on-disk size of the entry after its clone was updated.
+func (c *cloneCache) updated(entry *cloneCacheEntry) {
+	var size = dirSize(entry.path)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.size = size
+}
+
+// release marks the entry as no longer in use by the caller, and evicts clones if the cache is over capacity.
+func (c *cloneCache) release(entry *cloneCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.refs--
+	entry.lastUsed = time.Now()
+
+	c.evict()
+}
+
+// evict removes the least recently used clones that are not in use until the total size of the cache is below
+// maxSize. Clones locked by another worker sharing the base path are skipped. It must be called with c.mu held.
+func (c *cloneCache) evict() {
+	if c.maxSize <= 0 {
+		return
+	}
+
+	var total int64
+	var candidates []uuid.UUID
+	for id, entry := range c.entries {
+		total += entry.size
+		if entry.refs == 0 {
+			candidates = append(candidates, id)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return c.entries[candidates[i]].lastUsed.Before(c.entries[candidates[j]].lastUsed)
+	})
+
+	for _, id := range candidates {
+		if total <= c.maxSize {
+			return
+		}
+
+		var entry = c.entries[id]
+		var f, err = tryLock(entry.path + lockFileSuffix)
+		if err != nil || f == nil {
+			continue
+		}
+
+		err = os.RemoveAll(entry.path)
+		_ = f.Close()
+		if err != nil {
+			c.logger.Err(err).Msgf("could not evict repo clone at: %s, %v", entry.path, err)
+			continue
+		}
+
+		c.logger.Info().Msgf("evicted repo clone at: %s (%d bytes)", entry.path, entry.size)
+		total -= entry.size
+		delete(c.entries, id)
+	}
+}
+
+// dirSize returns the total size of all regular files under path, in bytes.
+func dirSize(path string) (size int64) {
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // skip anything we can't read
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
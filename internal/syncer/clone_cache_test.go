@@ -0,0 +1,136 @@
+package syncer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+func TestCloneCacheEvict(t *testing.T) {
+	type testEntry struct {
+		name   string
+		size   int64
+		refs   int
+		age    time.Duration // time since the entry was last used
+		locked bool          // true if the entry is locked by another worker
+	}
+
+	type testArgs struct {
+		description string
+		maxSize     int64
+		entries     []testEntry
+		want        []string // names of the entries left in the cache
+	}
+
+	tests := []testArgs{
+		{
+			description: "eviction disabled",
+			maxSize:     0,
+			entries:     []testEntry{{name: "a", size: 100, age: time.Hour}, {name: "b", size: 100, age: time.Minute}},
+			want:        []string{"a", "b"},
+		},
+		{
+			description: "below capacity",
+			maxSize:     200,
+			entries:     []testEntry{{name: "a", size: 100, age: time.Hour}, {name: "b", size: 100, age: time.Minute}},
+			want:        []string{"a", "b"},
+		},
+		{
+			description: "least recently used evicted first",
+			maxSize:     200,
+			entries: []testEntry{
+				{name: "a", size: 100, age: time.Minute},
+				{name: "b", size: 100, age: time.Hour},
+				{name: "c", size: 100, age: time.Second},
+			},
+			want: []string{"a", "c"},
+		},
+		{
+			description: "evicted until below capacity",
+			maxSize:     150,
+			entries: []testEntry{
+				{name: "a", size: 100, age: time.Minute},
+				{name: "b", size: 100, age: time.Hour},
+				{name: "c", size: 100, age: time.Second},
+			},
+			want: []string{"c"},
+		},
+		{
+			description: "entries in use are kept",
+			maxSize:     100,
+			entries: []testEntry{
+				{name: "a", size: 100, age: time.Minute},
+				{name: "b", size: 100, age: time.Hour, refs: 1},
+				{name: "c", size: 100, age: time.Second},
+			},
+			want: []string{"b"},
+		},
+		{
+			description: "entries locked by another worker are kept",
+			maxSize:     200,
+			entries: []testEntry{
+				{name: "a", size: 100, age: time.Minute},
+				{name: "b", size: 100, age: time.Hour, locked: true},
+				{name: "c", size: 100, age: time.Second},
+			},
+			want: []string{"b", "c"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			var logger = zerolog.Nop()
+			var c = newCloneCache(&logger, t.TempDir(), test.maxSize)
+
+			var names, paths = make(map[uuid.UUID]string), make(map[uuid.UUID]string)
+			for _, e := range test.entries {
+				var id = uuid.New()
+				var entry = c.acquire(id)
+				if err := os.MkdirAll(entry.path, 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(entry.path, "pack"), make([]byte, e.size), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				c.updated(entry)
+
+				if e.locked {
+					var f, err = tryLock(entry.path + lockFileSuffix)
+					if err != nil || f == nil {
+						t.Fatalf("could not lock entry %s: %v", e.name, err)
+					}
+					defer f.Close()
+				}
+
+				entry.refs, entry.lastUsed = e.refs, time.Now().Add(-e.age)
+				names[id], paths[id] = e.name, entry.path
+			}
+
+			c.mu.Lock()
+			c.evict()
+			c.mu.Unlock()
+
+			var got []string
+			for id, name := range names {
+				var _, ok = c.entries[id]
+				if _, err := os.Stat(paths[id]); ok != (err == nil) {
+					t.Fatalf("entry %s in cache: %v, but its clone exists: %v", name, ok, err == nil)
+				}
+				if ok {
+					got = append(got, name)
+				}
+			}
+			sort.Strings(got)
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("entries left after eviction = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
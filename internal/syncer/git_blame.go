@@ -3,24 +3,26 @@ package syncer
 import (
 	"bufio"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 	"unicode/utf8"
 
 	"github.com/go-enry/go-enry/v2"
 	"github.com/jackc/pgx/v4"
+	libgit2 "github.com/libgit2/git2go/v33"
 	"github.com/mergestat/gitutils/blame"
 	"github.com/mergestat/gitutils/lstree"
 	"github.com/mergestat/mergestat/internal/db"
-	"github.com/mergestat/mergestat/internal/helper"
 	uuid "github.com/satori/go.uuid"
 )
 
@@ -79,7 +81,7 @@ func (w *worker) sendBatchBlameLines(ctx context.Context, blameTmpPath string, t
 				line = nil
 			}
 
-			input := []interface{}{repoID, bl.AuthorEmail, bl.AuthorName, bl.AuthorWhen, bl.CommitHash, bl.LineNo, line, bl.Path}
+			input := []interface{}{repoID, bl.AuthorEmail, bl.AuthorName, bl.AuthorWhen, bl.CommitHash, bl.LineNo, line, bl.Path, bl.Ref}
 			inputs = append(inputs, input)
 
 			if len(inputs) == cap(inputs) {
@@ -87,7 +89,7 @@ func (w *worker) sendBatchBlameLines(ctx context.Context, blameTmpPath string, t
 			}
 		}
 
-		if _, err := tx.CopyFrom(ctx, pgx.Identifier{"git_blame"}, []string{"repo_id", "author_email", "author_name", "author_when", "commit_hash", "line_no", "line", "path"}, pgx.CopyFromRows(inputs)); err != nil {
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{"git_blame"}, []string{"repo_id", "author_email", "author_name", "author_when", "commit_hash", "line_no", "line", "path", "ref"}, pgx.CopyFromRows(inputs)); err != nil {
 			return 0, fmt.Errorf("tx copy from: %w", err)
 		}
 
@@ -113,147 +115,397 @@ type blameLine struct {
 	LineNo      *int
 	Line        *string
 	Path        *string
+	Ref         *string
 }
 
-func (w *worker) handleGitBlame(ctx context.Context, j *db.DequeueSyncJobRow) error {
-	var err error
-	l := w.loggerForJob(j)
+// blameChanges diffs the trees of the from and to commits, and returns the paths whose rows in git_blame
+// are outdated (changed, renamed or removed files), as well as the paths that need to be blamed again.
+func blameChanges(repo *libgit2.Repository, from, to *libgit2.Oid) (outdated, changed map[string]struct{}, err error) {
+	var trees = make([]*libgit2.Tree, 0, 2)
+	defer func() {
+		for _, tree := range trees {
+			tree.Free()
+		}
+	}()
 
-	if err := w.sendBatchLogMessages(ctx, []*syncLog{{Type: SyncLogTypeInfo, RepoSyncQueueID: j.ID,
-		Message: fmt.Sprintf(LogFormatStartingSync, j.SyncType, j.Repo),
-	}}); err != nil {
-		return fmt.Errorf("send batch log messages: %w", err)
+	for _, id := range []*libgit2.Oid{from, to} {
+		var commit *libgit2.Commit
+		if commit, err = repo.LookupCommit(id); err != nil {
+			return nil, nil, err
+		}
+
+		var tree *libgit2.Tree
+		tree, err = commit.Tree()
+		commit.Free()
+		if err != nil {
+			return nil, nil, err
+		}
+		trees = append(trees, tree)
 	}
 
-	tmpPath, cleanup, err := helper.CreateTempDir(os.Getenv("GIT_CLONE_PATH"), fmt.Sprintf("mergestat-repo-%s-*", j.RepoID.String()))
-	if err != nil {
-		return fmt.Errorf("temp dir: %w", err)
+	var diff *libgit2.Diff
+	if diff, err = repo.DiffTreeToTree(trees[0], trees[1], nil); err != nil {
+		return nil, nil, err
 	}
+	defer diff.Free()
 
-	defer func() {
-		if err = cleanup(); err != nil {
-			l.Err(err).Msgf("error cleaning up repo at: %s, %v", tmpPath, err)
+	var n int
+	if n, err = diff.NumDeltas(); err != nil {
+		return nil, nil, err
+	}
+
+	outdated, changed = make(map[string]struct{}, n), make(map[string]struct{}, n)
+	for i := 0; i < n; i++ {
+		var delta libgit2.DiffDelta
+		if delta, err = diff.Delta(i); err != nil {
+			return nil, nil, err
 		}
-	}()
 
-	if err = w.clone(ctx, tmpPath, j); err != nil {
-		return fmt.Errorf("git clone: %w", err)
+		outdated[delta.OldFile.Path] = struct{}{}
+		outdated[delta.NewFile.Path] = struct{}{}
+		if delta.Status != libgit2.DeltaDeleted {
+			changed[delta.NewFile.Path] = struct{}{}
+		}
 	}
 
-	iter, err := lstree.Exec(ctx, tmpPath, "HEAD", lstree.WithRecurse(true))
-	if err != nil {
-		return fmt.Errorf("git ls-tree error: %w", err)
-	}
+	return outdated, changed, nil
+}
 
-	var objects []*lstree.Object
-	for {
-		if o, err := iter.Next(); err != nil {
-			if errors.Is(err, io.EOF) {
-				break
+// blamePlan describes the work of a GIT_BLAME sync for a single ref
+type blamePlan struct {
+	tip         commitTip
+	incremental bool                            // true if only the files that changed since the last sync are blamed
+	outdated    map[string]struct{}             // paths whose rows are removed before inserting new ones, if incremental
+	paths       []string                        // paths of the files to blame
+	checkpoint  *db.MergestatRepoSyncCheckpoint // checkpoint recorded for the ref by the last sync, if any
+
+	mu     sync.Mutex
+	failed map[string]struct{} // paths of the files that could not be blamed, whose rows are kept as they are
+}
+
+// fail records that the file at the given path could not be blamed
+func (plan *blamePlan) fail(path string) {
+	plan.mu.Lock()
+	defer plan.mu.Unlock()
+	plan.failed[path] = struct{}{}
+}
+
+// planBlame determines the files to blame for the given ref. If the ref was blamed before (as recorded
+// in the checkpoints of the sync) with the same settings, only the files that changed since then need to be blamed again.
+func (w *worker) planBlame(ctx context.Context, j *db.DequeueSyncJobRow, settings *gitBlameSettings, repo *libgit2.Repository, repoPath string, tip commitTip, checkpoints []db.MergestatRepoSyncCheckpoint) (_ *blamePlan, err error) {
+	var l = w.loggerForJob(j)
+	var plan = &blamePlan{tip: tip, failed: make(map[string]struct{})}
+
+	var changed map[string]struct{}
+	for i, checkpoint := range checkpoints {
+		if checkpoint.Ref != tip.ref {
+			continue
+		}
+		plan.checkpoint = &checkpoints[i]
+
+		// files newly included by the settings were never blamed, and excluded ones must be removed
+		if checkpoint.SettingsHash.String != settings.hash() {
+			if err := w.sendBatchLogMessages(ctx, []*syncLog{{Type: SyncLogTypeInfo, RepoSyncQueueID: j.ID,
+				Message: fmt.Sprintf("settings of the sync changed since the last sync, blaming all files of %s", tip.ref),
+			}}); err != nil {
+				return nil, fmt.Errorf("send batch log messages: %w", err)
+			}
+			continue
+		}
+
+		if from, err := libgit2.NewOid(checkpoint.CommitHash); err == nil {
+			if plan.outdated, changed, err = blameChanges(repo, from, tip.id); err != nil {
+				// the previously blamed commit is gone (e.g. after a force push), fallback to blaming everything
+				l.Warn().AnErr("error", err).Msgf("could not diff against previously blamed commit: %s", checkpoint.CommitHash)
 			} else {
-				log.Fatal(err)
+				plan.incremental = true
 			}
-		} else {
-			objects = append(objects, o)
 		}
 	}
 
-	// creating a tmp file to store blame objects
-	var file *os.File
-	if file, err = os.CreateTemp(tmpPath, "blame-objects-*.json"); err != nil {
-		return err
+	if plan.incremental {
+		if err := w.sendBatchLogMessages(ctx, []*syncLog{{Type: SyncLogTypeInfo, RepoSyncQueueID: j.ID,
+			Message: fmt.Sprintf("%d file(s) changed in %s since the last sync, retrying %d file(s) that could not be blamed by it", len(plan.outdated), tip.ref, len(plan.checkpoint.FailedPaths)),
+		}}); err != nil {
+			return nil, fmt.Errorf("send batch log messages: %w", err)
+		}
+
+		// the files that could not be blamed by the last sync are blamed again, even if they didn't change
+		for _, path := range plan.checkpoint.FailedPaths {
+			plan.outdated[path] = struct{}{}
+			changed[path] = struct{}{}
+		}
 	}
 
-	defer file.Close()
+	iter, err := lstree.Exec(ctx, repoPath, tip.id.String(), lstree.WithRecurse(true))
+	if err != nil {
+		return nil, fmt.Errorf("git ls-tree error: %w", err)
+	}
 
-	encoder := json.NewEncoder(file)
+	for {
+		o, err := iter.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("git ls-tree error: %w", err)
+		}
 
-	for _, o := range objects {
 		if o.Type != "blob" {
 			continue
 		}
 
-		// skip running git blame on binary files
-		// first detect if a file is binary or not
-		fullPath := filepath.Join(tmpPath, o.Path)
-		if f, err := os.Open(fullPath); err != nil {
-			w.logger.Warn().AnErr("error", err).Str("repo", j.Repo).Msgf("error opening file in repo: %s, %v", fullPath, err)
+		if !settings.includes(o.Path) {
+			continue
+		}
+
+		if _, ok := changed[o.Path]; plan.incremental && !ok {
+			continue
+		}
+
+		// skip running git blame on binary and large files
+		var binary bool
+		var size int64
+		if binary, size, err = inspectBlob(repo, o.Hash); err != nil {
+			w.logger.Warn().AnErr("error", err).Str("repo", j.Repo).Msgf("error reading file in repo: %s, %v", o.Path, err)
 
 			// indicate that we're detecting unexpected behavior
 			if err := w.sendBatchLogMessages(ctx, []*syncLog{{Type: SyncLogTypeWarn, RepoSyncQueueID: j.ID,
-				Message: fmt.Sprintf(LogFormatErrorWarningMessage, "error opening file in repo", err),
+				Message: fmt.Sprintf(LogFormatErrorWarningMessage, "error reading file in repo", err),
 			}}); err != nil {
-				return fmt.Errorf("send batch log messages: %w", err)
+				return nil, fmt.Errorf("send batch log messages: %w", err)
 			}
 
 			continue
-		} else {
-			defer f.Close()
-
-			// only read the first 8kb of the file to detect if it's binary or not
-			buffer := make([]byte, 8000)
-			var bytesRead int
-			if bytesRead, err = f.Read(buffer); err != nil && !errors.Is(err, io.EOF) {
-				w.logger.Warn().AnErr("error", err).Str("repo", j.Repo).Msgf("error reading file in repo: %s, %v", fullPath, err)
-
-				// indicate that we're detecting unexpected behavior
-				if err := w.sendBatchLogMessages(ctx, []*syncLog{{Type: SyncLogTypeWarn, RepoSyncQueueID: j.ID,
-					Message: fmt.Sprintf(LogFormatErrorWarningMessage, "error reading file in repo", err),
-				}}); err != nil {
-					return fmt.Errorf("send batch log messages: %w", err)
-				}
-			}
+		}
 
-			// See here: https://github.com/go-enry/go-enry/blob/v2.8.2/utils.go#L80 for the implementation of IsBinary
-			// basically just looking for a byte(0) in the first portion of the file
-			if enry.IsBinary(buffer[:bytesRead]) {
-				w.logger.Info().Msgf("skipping binary file: %s", fullPath)
-				// TODO(patrickdevivo) maybe we should also log to the DB so the user can see this?
-				continue
-			}
+		if binary {
+			w.logger.Info().Msgf("skipping binary file: %s", o.Path)
+			// TODO(patrickdevivo) maybe we should also log to the DB so the user can see this?
+			continue
 		}
 
-		// adjustedBufferSize is larger than the default to support longer lines without error
-		// TODO(patrickdevivo) maybe eventually we can make this configurable? Either via an ENV var or a DB setting
-		adjustedBufferSize := bufio.MaxScanTokenSize * 30
-		res, err := blame.Exec(ctx, tmpPath, o.Path, blame.WithScannerBuffer(make([]byte, adjustedBufferSize), adjustedBufferSize))
-		if err != nil {
-			l := w.logger.Warn().AnErr("error", err).Str("repo", j.Repo).Str("filePath", o.Path)
-			if exitErr, ok := err.(*exec.ExitError); ok {
-				l.Msgf("error blaming file: %s in repo: %s, %v: %s", o.Path, tmpPath, err, exitErr.Stderr)
-			} else {
-				l.Msgf("error blaming file: %s in repo: %s, %v", o.Path, tmpPath, err)
-			}
+		if settings.MaxFileSize > 0 && size > settings.MaxFileSize {
+			w.logger.Info().Msgf("skipping file larger than %d bytes: %s", settings.MaxFileSize, o.Path)
+			continue
+		}
 
-			// indicate that we're detecting unexpected behavior
-			if err := w.sendBatchLogMessages(ctx, []*syncLog{{Type: SyncLogTypeWarn, RepoSyncQueueID: j.ID,
-				Message: fmt.Sprintf(LogFormatErrorWarningMessage, "error blaming file in repo", err),
-			}}); err != nil {
-				return fmt.Errorf("send batch log messages: %w", err)
+		plan.paths = append(plan.paths, o.Path)
+	}
+
+	return plan, nil
+}
+
+// inspectBlob reports whether the blob with the given hash holds binary contents, and its size in bytes
+func inspectBlob(repo *libgit2.Repository, hash string) (binary bool, size int64, err error) {
+	id, err := libgit2.NewOid(hash)
+	if err != nil {
+		return false, 0, err
+	}
+
+	blob, err := repo.LookupBlob(id)
+	if err != nil {
+		return false, 0, err
+	}
+	defer blob.Free()
+
+	// only look at the first 8kb of the file to detect if it's binary or not
+	// See here: https://github.com/go-enry/go-enry/blob/v2.8.2/utils.go#L80 for the implementation of IsBinary
+	// basically just looking for a byte(0) in the first portion of the file
+	var contents = blob.Contents()
+	if len(contents) > 8000 {
+		contents = contents[:8000]
+	}
+
+	return enry.IsBinary(contents), blob.Size(), nil
+}
+
+// blameFiles runs git blame on the files of the plan using a pool of goroutines, sized by the "concurrency" setting
+// of the sync (defaults to the number of CPUs), and streams the blamed lines into the encoder as files complete.
+func (w *worker) blameFiles(ctx context.Context, j *db.DequeueSyncJobRow, settings *gitBlameSettings, repoPath string, plan *blamePlan, encoder *json.Encoder) error {
+	var concurrency = settings.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		queue   = make(chan string)
+		results = make(chan []*blameLine)
+		errs    = make(chan error, concurrency)
+		wg      sync.WaitGroup
+	)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range queue {
+				lines, blamed, err := w.blameFile(ctx, j, repoPath, plan.tip, path)
+				if err != nil {
+					errs <- err
+					cancel()
+					return
+				}
+
+				if !blamed {
+					plan.fail(path)
+					continue
+				}
+
+				select {
+				case results <- lines:
+				case <-ctx.Done():
+					return
+				}
 			}
+		}()
+	}
 
-			continue
+	go func() {
+		defer close(queue)
+		for _, path := range plan.paths {
+			select {
+			case queue <- path:
+			case <-ctx.Done():
+				return
+			}
 		}
+	}()
 
-		for lineIdx, blame := range res {
-			lineNo := lineIdx + 1
-			blameline := &blameLine{
-				AuthorEmail: &blame.Author.Email,
-				AuthorName:  &blame.Author.Name,
-				AuthorWhen:  &blame.Author.When,
-				CommitHash:  &blame.SHA,
-				LineNo:      &lineNo,
-				Line:        &blame.Line,
-				Path:        &o.Path,
-			}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
 
+	// a single consumer writes to the encoder, so lines of a file are never interleaved with another's
+	for lines := range results {
+		for _, blameline := range lines {
 			// encoding each blame line to a json file
-			if err = encoder.Encode(blameline); err != nil {
+			if err := encoder.Encode(blameline); err != nil {
 				w.logger.Err(err).Msgf("%v", err)
 			}
 		}
 	}
 
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return ctx.Err()
+	}
+}
+
+// blameFile runs git blame on a single file of the repo, at the commit of the given ref. Files that could
+// not be blamed are skipped (with a warning logged to the sync), in which case false is returned.
+func (w *worker) blameFile(ctx context.Context, j *db.DequeueSyncJobRow, repoPath string, tip commitTip, path string) ([]*blameLine, bool, error) {
+	// adjustedBufferSize is larger than the default to support longer lines without error
+	// TODO(patrickdevivo) maybe eventually we can make this configurable? Either via an ENV var or a DB setting
+	adjustedBufferSize := bufio.MaxScanTokenSize * 30
+	res, err := blame.Exec(ctx, repoPath, path, blame.WithRevision(tip.id.String()), blame.WithScannerBuffer(make([]byte, adjustedBufferSize), adjustedBufferSize))
+	if err != nil {
+		// the sync is being stopped, so this isn't an issue with the file
+		if ctx.Err() != nil {
+			return nil, false, ctx.Err()
+		}
+
+		l := w.logger.Warn().AnErr("error", err).Str("repo", j.Repo).Str("filePath", path)
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			l.Msgf("error blaming file: %s in repo: %s, %v: %s", path, repoPath, err, exitErr.Stderr)
+		} else {
+			l.Msgf("error blaming file: %s in repo: %s, %v", path, repoPath, err)
+		}
+
+		// indicate that we're detecting unexpected behavior
+		if err := w.sendBatchLogMessages(ctx, []*syncLog{{Type: SyncLogTypeWarn, RepoSyncQueueID: j.ID,
+			Message: fmt.Sprintf(LogFormatErrorWarningMessage, "error blaming file in repo", err),
+		}}); err != nil {
+			return nil, false, fmt.Errorf("send batch log messages: %w", err)
+		}
+
+		return nil, false, nil
+	}
+
+	var lines = make([]*blameLine, 0, len(res))
+	for lineIdx, blame := range res {
+		lineNo := lineIdx + 1
+		lines = append(lines, &blameLine{
+			AuthorEmail: &blame.Author.Email,
+			AuthorName:  &blame.Author.Name,
+			AuthorWhen:  &blame.Author.When,
+			CommitHash:  &blame.SHA,
+			LineNo:      &lineNo,
+			Line:        &blame.Line,
+			Path:        &path,
+			Ref:         &tip.ref,
+		})
+	}
+
+	return lines, true, nil
+}
+
+func (w *worker) handleGitBlame(ctx context.Context, j *db.DequeueSyncJobRow) error {
+	var err error
+	l := w.loggerForJob(j)
+
+	if err := w.sendBatchLogMessages(ctx, []*syncLog{{Type: SyncLogTypeInfo, RepoSyncQueueID: j.ID,
+		Message: fmt.Sprintf(LogFormatStartingSync, j.SyncType, j.Repo),
+	}}); err != nil {
+		return fmt.Errorf("send batch log messages: %w", err)
+	}
+
+	repoPath, release, err := w.checkout(ctx, j)
+	if err != nil {
+		return fmt.Errorf("git clone: %w", err)
+	}
+	defer release()
+
+	var repo *libgit2.Repository
+	if repo, err = libgit2.OpenRepository(repoPath); err != nil {
+		return fmt.Errorf("open repo: %w", err)
+	}
+	defer repo.Free()
+
+	var settings gitBlameSettings
+	if err = parseSettings(j, &settings); err != nil {
+		return err
+	}
+
+	var tips []commitTip
+	if tips, err = resolveRefs(repo, j); err != nil {
+		return fmt.Errorf("resolve refs: %w", err)
+	}
+
+	var checkpoints []db.MergestatRepoSyncCheckpoint
+	if checkpoints, err = w.db.ListRepoSyncCheckpoints(ctx, j.RepoSyncID); err != nil {
+		return fmt.Errorf("list checkpoints: %w", err)
+	}
+
+	// creating a tmp file to store blame objects, outside the (shared) clone
+	var file *os.File
+	if file, err = os.CreateTemp(os.Getenv("GIT_CLONE_PATH"), "blame-objects-*.json"); err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	var encoder = json.NewEncoder(file)
+	var plans = make([]*blamePlan, 0, len(tips))
+	for _, tip := range tips {
+		var plan *blamePlan
+		if plan, err = w.planBlame(ctx, j, &settings, repo, repoPath, tip, checkpoints); err != nil {
+			return err
+		}
+
+		if err = w.blameFiles(ctx, j, &settings, repoPath, plan, encoder); err != nil {
+			return err
+		}
+
+		plans = append(plans, plan)
+	}
+
 	var tx pgx.Tx
 	if tx, err = w.pool.BeginTx(ctx, pgx.TxOptions{}); err != nil {
 		return fmt.Errorf("begin tx: %w", err)
@@ -266,15 +518,47 @@ func (w *worker) handleGitBlame(ctx context.Context, j *db.DequeueSyncJobRow) er
 		}
 	}()
 
-	r, err := tx.Exec(ctx, "DELETE FROM git_blame WHERE repo_id = $1;", j.RepoID.String())
+	// remove rows of refs that are no longer synced, and the outdated rows of the ones that are
+	var refs = make([]string, 0, len(plans))
+	for _, plan := range plans {
+		refs = append(refs, plan.tip.ref)
+	}
+
+	r, err := tx.Exec(ctx, "DELETE FROM git_blame WHERE repo_id = $1 AND NOT (ref = ANY($2));", j.RepoID.String(), refs)
 	if err != nil {
 		return fmt.Errorf("exec delete: %w", err)
 	}
+	var removed = r.RowsAffected()
+
+	for _, plan := range plans {
+		// the rows of files that could not be blamed are kept, rather than losing their blame
+		var failed = make([]string, 0, len(plan.failed))
+		for path := range plan.failed {
+			failed = append(failed, path)
+		}
+
+		if plan.incremental {
+			var paths = make([]string, 0, len(plan.outdated))
+			for path := range plan.outdated {
+				if _, ok := plan.failed[path]; !ok {
+					paths = append(paths, path)
+				}
+			}
+
+			r, err = tx.Exec(ctx, "DELETE FROM git_blame WHERE repo_id = $1 AND ref = $2 AND path = ANY($3);", j.RepoID.String(), plan.tip.ref, paths)
+		} else {
+			r, err = tx.Exec(ctx, "DELETE FROM git_blame WHERE repo_id = $1 AND ref = $2 AND NOT (path = ANY($3));", j.RepoID.String(), plan.tip.ref, failed)
+		}
+		if err != nil {
+			return fmt.Errorf("exec delete: %w", err)
+		}
+		removed += r.RowsAffected()
+	}
 
 	if err := w.sendBatchLogMessages(ctx, []*syncLog{{
 		Type:            SyncLogTypeInfo,
 		RepoSyncQueueID: j.ID,
-		Message:         fmt.Sprintf("removed %d row(s) from git_blame", r.RowsAffected()),
+		Message:         fmt.Sprintf("removed %d row(s) from git_blame", removed),
 	}}); err != nil {
 		return err
 	}
@@ -282,6 +566,7 @@ func (w *worker) handleGitBlame(ctx context.Context, j *db.DequeueSyncJobRow) er
 	if blamedLines, err = w.sendBatchBlameLines(ctx, file.Name(), tx, j); err != nil {
 		return fmt.Errorf("send batch blamed lines: %w", err)
 	}
+	rowsInserted(j, "git_blame", blamedLines)
 
 	l.Info().Msgf("sent batch of %d blamed lines", blamedLines)
 
@@ -293,6 +578,29 @@ func (w *worker) handleGitBlame(ctx context.Context, j *db.DequeueSyncJobRow) er
 		return err
 	}
 
+	// record the blamed commits, so the next sync only blames what changed since then, along with
+	// the files that could not be blamed, so that the next sync tries to blame them again.
+	if err := w.db.WithTx(tx).DeleteRepoSyncCheckpoints(ctx, j.RepoSyncID); err != nil {
+		return fmt.Errorf("delete checkpoints: %w", err)
+	}
+
+	for _, plan := range plans {
+		var failed = make([]string, 0, len(plan.failed))
+		for path := range plan.failed {
+			failed = append(failed, path)
+		}
+		sort.Strings(failed)
+
+		var checkpoint = db.UpsertRepoSyncCheckpointParams{
+			RepoSyncID: j.RepoSyncID, Ref: plan.tip.ref, CommitHash: plan.tip.id.String(),
+			SettingsHash: sql.NullString{String: settings.hash(), Valid: true}, FailedPaths: failed,
+		}
+
+		if err := w.db.WithTx(tx).UpsertRepoSyncCheckpoint(ctx, checkpoint); err != nil {
+			return fmt.Errorf("upsert checkpoint: %w", err)
+		}
+	}
+
 	if err := w.db.WithTx(tx).SetSyncJobStatus(ctx, db.SetSyncJobStatusParams{Status: "DONE", ID: j.ID}); err != nil {
 		return fmt.Errorf("update status done: %w", err)
 	}
@@ -0,0 +1,130 @@
+package syncer
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	libgit2 "github.com/libgit2/git2go/v33"
+)
+
+// commitFiles replaces the contents of the worktree at dir with the given files, commits them and returns the id of the commit
+func commitFiles(t *testing.T, dir string, files map[string]string) *libgit2.Oid {
+	t.Helper()
+
+	var git = func(args ...string) string {
+		var cmd = exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	git("rm", "-r", "-q", "--ignore-unmatch", ".")
+	for name, contents := range files {
+		var p = filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("add", "-A")
+	git("-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "commit")
+
+	id, err := libgit2.NewOid(git("rev-parse", "HEAD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestBlameChanges(t *testing.T) {
+	type testArgs struct {
+		description  string
+		from         map[string]string
+		to           map[string]string
+		wantOutdated []string
+		wantChanged  []string
+	}
+
+	tests := []testArgs{
+		{
+			description: "no changes",
+			from:        map[string]string{"main.go": "package main\n"},
+			to:          map[string]string{"main.go": "package main\n"},
+		},
+		{
+			description:  "modified file",
+			from:         map[string]string{"main.go": "package main\n", "README.md": "# readme\n"},
+			to:           map[string]string{"main.go": "package main\n\nfunc main() {}\n", "README.md": "# readme\n"},
+			wantOutdated: []string{"main.go"},
+			wantChanged:  []string{"main.go"},
+		},
+		{
+			description:  "added file",
+			from:         map[string]string{"main.go": "package main\n"},
+			to:           map[string]string{"main.go": "package main\n", "pkg/util.go": "package pkg\n"},
+			wantOutdated: []string{"pkg/util.go"},
+			wantChanged:  []string{"pkg/util.go"},
+		},
+		{
+			description:  "removed file",
+			from:         map[string]string{"main.go": "package main\n", "pkg/util.go": "package pkg\n"},
+			to:           map[string]string{"main.go": "package main\n"},
+			wantOutdated: []string{"pkg/util.go"},
+		},
+		{
+			description:  "renamed file",
+			from:         map[string]string{"main.go": "package main\n", "util.go": "package main\n\nfunc util() {}\n"},
+			to:           map[string]string{"main.go": "package main\n", "helpers.go": "package main\n\nfunc util() {}\n"},
+			wantOutdated: []string{"helpers.go", "util.go"},
+			wantChanged:  []string{"helpers.go"},
+		},
+	}
+
+	var keys = func(m map[string]struct{}) []string {
+		var k = make([]string, 0, len(m))
+		for p := range m {
+			k = append(k, p)
+		}
+		sort.Strings(k)
+		return k
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			var dir = t.TempDir()
+			if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+				t.Fatalf("git init: %v: %s", err, out)
+			}
+
+			var from, to = commitFiles(t, dir, test.from), commitFiles(t, dir, test.to)
+
+			repo, err := libgit2.OpenRepository(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer repo.Free()
+
+			outdated, changed, err := blameChanges(repo, from, to)
+			if err != nil {
+				t.Fatalf("blameChanges() returned error: %v", err)
+			}
+
+			if got, want := keys(outdated), append([]string{}, test.wantOutdated...); !reflect.DeepEqual(got, want) {
+				t.Errorf("blameChanges() outdated = %q, want %q", got, want)
+			}
+			if got, want := keys(changed), append([]string{}, test.wantChanged...); !reflect.DeepEqual(got, want) {
+				t.Errorf("blameChanges() changed = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -5,12 +5,10 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"os"
 
 	"github.com/jackc/pgx/v4"
 	libgit2 "github.com/libgit2/git2go/v33"
 	"github.com/mergestat/mergestat/internal/db"
-	"github.com/mergestat/mergestat/internal/helper"
 	uuid "github.com/satori/go.uuid"
 )
 
@@ -80,24 +78,15 @@ func (w *worker) handleGitCommitStats(ctx context.Context, j *db.DequeueSyncJobR
 		return fmt.Errorf("send batch log messages: %w", err)
 	}
 
-	tmpPath, cleanup, err := helper.CreateTempDir(os.Getenv("GIT_CLONE_PATH"), fmt.Sprintf("mergestat-repo-%s-*", j.RepoID.String()))
+	repoPath, release, err := w.checkout(ctx, j)
 	if err != nil {
-		return fmt.Errorf("temp dir: %w", err)
-	}
-	defer func() {
-		if err = cleanup(); err != nil {
-			l.Err(err).Msgf("error cleaning up repo at: %s, %v", tmpPath, err)
-
-		}
-	}()
-
-	if err = w.clone(ctx, tmpPath, j); err != nil {
 		return fmt.Errorf("git clone: %w", err)
 	}
+	defer release()
 
 	var stats = make([]*commitStat, 0)
 	var repo *libgit2.Repository
-	if repo, err = libgit2.OpenRepository(tmpPath); err != nil {
+	if repo, err = libgit2.OpenRepository(repoPath); err != nil {
 		return err
 	}
 
@@ -109,8 +98,16 @@ func (w *worker) handleGitCommitStats(ctx context.Context, j *db.DequeueSyncJobR
 	}
 	defer walk.Free()
 
-	if err := walk.PushHead(); err != nil {
-		return err
+	// walk the history of the same refs as GIT_COMMITS does
+	var tips []commitTip
+	if tips, err = resolveRefs(repo, j); err != nil {
+		return fmt.Errorf("resolve refs: %w", err)
+	}
+
+	for _, tip := range tips {
+		if err := walk.Push(tip.id); err != nil {
+			return err
+		}
 	}
 
 	if err := walk.Iterate(func(c *libgit2.Commit) bool {
@@ -221,6 +218,7 @@ func (w *worker) handleGitCommitStats(ctx context.Context, j *db.DequeueSyncJobR
 	if err := w.sendBatchCommitStats(ctx, tx, j, stats); err != nil {
 		return nil
 	}
+	rowsInserted(j, "git_commit_stats", len(stats))
 
 	l.Info().Msgf("imported %d commit stats", len(stats))
 
@@ -16,8 +16,8 @@ import (
 	uuid "github.com/satori/go.uuid"
 )
 
-// sendBatchCommits uses the pg COPY protocol to send a batch of commits
-func (w *worker) sendBatchCommits(ctx context.Context, tx pgx.Tx, j *db.DequeueSyncJobRow, jsonTmpPath string) (int, error) {
+// sendBatchCommits uses the pg COPY protocol to send a batch of commits into the given table, and their trailers into the given trailers table
+func (w *worker) sendBatchCommits(ctx context.Context, tx pgx.Tx, j *db.DequeueSyncJobRow, table, trailersTable, jsonTmpPath string) (int, error) {
 	var (
 		f   *os.File
 		err error
@@ -32,6 +32,7 @@ func (w *worker) sendBatchCommits(ctx context.Context, tx pgx.Tx, j *db.DequeueS
 
 	var (
 		inputs          = make([][]interface{}, 0, 100)
+		trailers        = make([][]interface{}, 0, 100)
 		insertedCommits = 0
 		isEOF           = false
 		repoID          uuid.UUID
@@ -61,21 +62,33 @@ func (w *worker) sendBatchCommits(ctx context.Context, tx pgx.Tx, j *db.DequeueS
 			input := []interface{}{repoID, c.Hash.String, c.Message.String,
 				c.AuthorName.String, c.AuthorEmail.String, c.AuthorWhen.Time,
 				c.CommitterName.String, c.CommitterEmail.String, c.CommitterWhen.Time,
-				c.Parents.Int32,
+				c.Parents.Int32, c.Ref.String, c.ParentHashes, c.TreeHash.String, c.SignatureType, c.SignerKeyID,
+				c.ConventionalType, c.ConventionalScope, c.IsBreakingChange,
 			}
 			inputs = append(inputs, input)
 
+			for i, t := range c.Trailers {
+				var name, email, isIdentity = helper.ParseIdentity(t.Value)
+				trailers = append(trailers, []interface{}{repoID, c.Hash.String, i, t.Key, t.Value,
+					sql.NullString{String: name, Valid: isIdentity}, sql.NullString{String: email, Valid: isIdentity},
+				})
+			}
+
 			if len(inputs) == cap(inputs) {
 				break
 			}
 		}
-		if _, err := tx.CopyFrom(ctx, pgx.Identifier{"git_commits"}, []string{"repo_id", "hash", "message", "author_name", "author_email", "author_when", "committer_name", "committer_email", "committer_when", "parents"}, pgx.CopyFromRows(inputs)); err != nil {
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{table}, []string{"repo_id", "hash", "message", "author_name", "author_email", "author_when", "committer_name", "committer_email", "committer_when", "parents", "ref", "parent_hashes", "tree_hash", "signature_type", "signer_key_id", "conventional_type", "conventional_scope", "is_breaking_change"}, pgx.CopyFromRows(inputs)); err != nil {
 			return 0, err
 		}
 		insertedCommits += len(inputs)
 
-		//cleaning slice and keeping capacity
-		inputs = inputs[:0]
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{trailersTable}, []string{"repo_id", "commit_hash", "position", "key", "value", "name", "email"}, pgx.CopyFromRows(trailers)); err != nil {
+			return 0, err
+		}
+
+		//cleaning slices and keeping capacity
+		inputs, trailers = inputs[:0], trailers[:0]
 
 		// if we reach EOF we exit
 		if isEOF {
@@ -96,15 +109,64 @@ type commit struct {
 	CommitterEmail sql.NullString `db:"committer_email"`
 	CommitterWhen  sql.NullTime   `db:"committer_when"`
 	Parents        sql.NullInt32  `db:"parents"`
+	Ref            sql.NullString `db:"ref"`
+	ParentHashes   []string       `db:"parent_hashes"`
+	TreeHash       sql.NullString `db:"tree_hash"`
+	SignatureType  sql.NullString `db:"signature_type"`
+	SignerKeyID    sql.NullString `db:"signer_key_id"`
+
+	ConventionalType  sql.NullString   `db:"conventional_type"`
+	ConventionalScope sql.NullString   `db:"conventional_scope"`
+	IsBreakingChange  sql.NullBool     `db:"is_breaking_change"`
+	Trailers          []helper.Trailer `db:"-"` // sent into git_commit_trailers
 }
 
-// collectCommits retrieves all the commits for a given repository and returns them as a slice
-func (w *worker) collectCommits(ctx context.Context, tmpPath string) (string, error) {
+// incrementalBase returns the previously synced commits that can be excluded from the walk of the given tips.
+// It returns false if there's no previous sync, or if history was rewritten (a synced ref was removed or force-pushed),
+// in which case a full reload is needed.
+func incrementalBase(repo *libgit2.Repository, checkpoints []db.MergestatRepoSyncCheckpoint, tips []commitTip) ([]*libgit2.Oid, bool) {
+	if len(checkpoints) == 0 {
+		return nil, false
+	}
+
+	var base = make([]*libgit2.Oid, 0, len(checkpoints))
+	for _, checkpoint := range checkpoints {
+		var tip *libgit2.Oid
+		for _, t := range tips {
+			if t.ref == checkpoint.Ref {
+				tip = t.id
+			}
+		}
+		if tip == nil {
+			return nil, false
+		}
+
+		old, err := libgit2.NewOid(checkpoint.CommitHash)
+		if err != nil {
+			return nil, false
+		}
+
+		// the previously synced commit must still be part of the ref's history, else the ref was force-pushed
+		if !old.Equal(tip) {
+			if ok, err := repo.DescendantOf(tip, old); err != nil || !ok {
+				return nil, false
+			}
+		}
+
+		base = append(base, old)
+	}
+
+	return base, true
+}
+
+// collectCommits retrieves the commits reachable from tips but not from any of the hidden commits,
+// and writes them to a json file, returning the path of the file. Each commit is attributed to the
+// first of the tips it is reachable from.
+func (w *worker) collectCommits(ctx context.Context, repo *libgit2.Repository, tips []commitTip, hidden []*libgit2.Oid) (string, error) {
 	var err error
-	var repo *libgit2.Repository
 
 	var f *os.File
-	if f, err = os.CreateTemp(tmpPath, "commits-objects-*.json"); err != nil {
+	if f, err = os.CreateTemp(os.Getenv("GIT_CLONE_PATH"), "commits-objects-*.json"); err != nil {
 		return "", err
 	}
 
@@ -112,28 +174,50 @@ func (w *worker) collectCommits(ctx context.Context, tmpPath string) (string, er
 
 	encoder := json.NewEncoder(f)
 
-	if repo, err = libgit2.OpenRepository(tmpPath); err != nil {
-		return "", err
+	for i, tip := range tips {
+		if err = w.walkCommits(ctx, repo, tip, append(tipIDs(tips[:i]), hidden...), encoder); err != nil {
+			_ = os.Remove(f.Name()) // the partial history isn't written
+			return "", err
+		}
 	}
 
-	defer repo.Free()
+	return f.Name(), nil
+}
+
+// tipIDs returns the commit ids of the given tips
+func tipIDs(tips []commitTip) []*libgit2.Oid {
+	var ids = make([]*libgit2.Oid, 0, len(tips))
+	for _, tip := range tips {
+		ids = append(ids, tip.id)
+	}
+	return ids
+}
 
+// walkCommits encodes the commits reachable from tip but not from any of the hidden commits
+func (w *worker) walkCommits(ctx context.Context, repo *libgit2.Repository, tip commitTip, hidden []*libgit2.Oid, encoder *json.Encoder) (err error) {
 	walk, err := repo.Walk()
 	if err != nil {
-		return "", err
+		return err
 	}
 	defer walk.Free()
 
-	if err := walk.PushHead(); err != nil {
-		return "", err
+	if err := walk.Push(tip.id); err != nil {
+		return err
+	}
+
+	for _, id := range hidden {
+		if err := walk.Hide(id); err != nil {
+			return err
+		}
 	}
 
 	if err := walk.Iterate(func(c *libgit2.Commit) bool {
 		defer c.Free()
 
-		// TODO(patrickdevivo) inspect this behavior
+		// a walk interrupted by the ctx is an error, so that the partial history is neither written nor checkpointed
 		select {
 		case <-ctx.Done():
+			err = ctx.Err()
 			return false
 		default:
 		}
@@ -148,6 +232,28 @@ func (w *worker) collectCommits(ctx context.Context, tmpPath string) (string, er
 		r.CommitterEmail = sql.NullString{String: c.Committer().Email, Valid: true}
 		r.CommitterWhen = sql.NullTime{Time: c.Committer().When, Valid: true}
 		r.Parents = sql.NullInt32{Int32: int32(c.ParentCount()), Valid: true}
+		r.Ref = sql.NullString{String: tip.ref, Valid: true}
+		r.TreeHash = sql.NullString{String: c.TreeId().String(), Valid: true}
+
+		r.ParentHashes = make([]string, 0, c.ParentCount())
+		for i := uint(0); i < c.ParentCount(); i++ {
+			r.ParentHashes = append(r.ParentHashes, c.ParentId(i).String())
+		}
+
+		// the key of a signature that can't be parsed isn't recorded, but the rest of the commit is
+		sigType, keyID, sigErr := commitSignature(c)
+		if sigErr != nil {
+			w.logger.Warn().AnErr("error", sigErr).Msgf("could not read the signature of commit %s", c.Id())
+		}
+		r.SignatureType = sql.NullString{String: sigType, Valid: sigType != ""}
+		r.SignerKeyID = sql.NullString{String: keyID, Valid: keyID != ""}
+
+		r.Trailers = helper.ParseTrailers(c.Message())
+		if cc, ok := helper.ParseConventionalCommit(c.Message(), r.Trailers); ok {
+			r.ConventionalType = sql.NullString{String: cc.Type, Valid: true}
+			r.ConventionalScope = sql.NullString{String: cc.Scope, Valid: cc.Scope != ""}
+			r.IsBreakingChange = sql.NullBool{Bool: cc.Breaking, Valid: true}
+		}
 
 		// encode commit object to json file
 		if err = encoder.Encode(r); err != nil {
@@ -157,10 +263,10 @@ func (w *worker) collectCommits(ctx context.Context, tmpPath string) (string, er
 
 		return true
 	}); err != nil {
-		return "", err
+		return err
 	}
 
-	return f.Name(), nil
+	return err
 }
 
 func (w *worker) handleGitCommits(ctx context.Context, j *db.DequeueSyncJobRow) error {
@@ -174,21 +280,41 @@ func (w *worker) handleGitCommits(ctx context.Context, j *db.DequeueSyncJobRow)
 		return fmt.Errorf("send batch log messages: %w", err)
 	}
 
-	tmpPath, cleanup, err := helper.CreateTempDir(os.Getenv("GIT_CLONE_PATH"), fmt.Sprintf("mergestat-repo-%s-*", j.RepoID.String()))
+	repoPath, release, err := w.checkout(ctx, j)
 	if err != nil {
-		return fmt.Errorf("temp dir: %w", err)
+		return fmt.Errorf("git clone: %w", err)
 	}
-	defer func() {
-		if err = cleanup(); err != nil {
-			l.Err(err).Msgf("error cleaning up repo at: %s, %v", tmpPath, err)
-		}
-	}()
+	defer release()
 
-	if err = w.clone(ctx, tmpPath, j); err != nil {
-		return fmt.Errorf("git clone: %w", err)
+	var repo *libgit2.Repository
+	if repo, err = libgit2.OpenRepository(repoPath); err != nil {
+		return err
+	}
+	defer repo.Free()
+
+	var tips []commitTip
+	if tips, err = resolveRefs(repo, j); err != nil {
+		return fmt.Errorf("resolve refs: %w", err)
 	}
 
-	jsonTmpPath, err := w.collectCommits(ctx, tmpPath)
+	var checkpoints []db.MergestatRepoSyncCheckpoint
+	if checkpoints, err = w.db.ListRepoSyncCheckpoints(ctx, j.RepoSyncID); err != nil {
+		return fmt.Errorf("list checkpoints: %w", err)
+	}
+
+	// only walk the commits that are new since the last sync, unless history was rewritten
+	hidden, incremental := incrementalBase(repo, checkpoints, tips)
+	if !incremental && len(checkpoints) > 0 {
+		if err := w.sendBatchLogMessages(ctx, []*syncLog{{
+			Type:            SyncLogTypeWarn,
+			RepoSyncQueueID: j.ID,
+			Message:         "history was rewritten since the last sync, reloading all commits",
+		}}); err != nil {
+			return err
+		}
+	}
+
+	jsonTmpPath, err := w.collectCommits(ctx, repo, tips, hidden)
 	if err != nil {
 		return err
 	}
@@ -205,22 +331,51 @@ func (w *worker) handleGitCommits(ctx context.Context, j *db.DequeueSyncJobRow)
 		}
 	}()
 
-	r, err := tx.Exec(ctx, "DELETE FROM git_commits WHERE repo_id = $1;", j.RepoID.String())
-	if err != nil {
-		return err
-	}
-
-	if err := w.sendBatchLogMessages(ctx, []*syncLog{{
-		Type:            SyncLogTypeInfo,
-		RepoSyncQueueID: j.ID,
-		Message:         fmt.Sprintf("removed %d row(s) from git_commits", r.RowsAffected()),
-	}}); err != nil {
-		return err
-	}
 	var insertedCommits int
-	if insertedCommits, err = w.sendBatchCommits(ctx, tx, j, jsonTmpPath); err != nil {
-		return err
+	if incremental {
+		// new commits might already be present (e.g. when reachable from a newly synced ref), so they're
+		// first copied into a staging table, and then inserted into git_commits skipping existing ones
+		if _, err := tx.Exec(ctx, "CREATE TEMP TABLE _mergestat_git_commits (LIKE git_commits INCLUDING DEFAULTS) ON COMMIT DROP;"); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, "CREATE TEMP TABLE _mergestat_git_commit_trailers (LIKE git_commit_trailers INCLUDING DEFAULTS) ON COMMIT DROP;"); err != nil {
+			return err
+		}
+
+		if _, err = w.sendBatchCommits(ctx, tx, j, "_mergestat_git_commits", "_mergestat_git_commit_trailers", jsonTmpPath); err != nil {
+			return err
+		}
+
+		r, err := tx.Exec(ctx, "INSERT INTO git_commits SELECT * FROM _mergestat_git_commits ON CONFLICT (repo_id, hash) DO NOTHING;")
+		if err != nil {
+			return err
+		}
+		insertedCommits = int(r.RowsAffected())
+
+		if _, err := tx.Exec(ctx, "INSERT INTO git_commit_trailers SELECT * FROM _mergestat_git_commit_trailers ON CONFLICT (repo_id, commit_hash, position) DO NOTHING;"); err != nil {
+			return err
+		}
+	} else {
+		// the trailers of the commits are deleted along with them
+		r, err := tx.Exec(ctx, "DELETE FROM git_commits WHERE repo_id = $1;", j.RepoID.String())
+		if err != nil {
+			return err
+		}
+
+		if err := w.sendBatchLogMessages(ctx, []*syncLog{{
+			Type:            SyncLogTypeInfo,
+			RepoSyncQueueID: j.ID,
+			Message:         fmt.Sprintf("removed %d row(s) from git_commits", r.RowsAffected()),
+		}}); err != nil {
+			return err
+		}
+
+		if insertedCommits, err = w.sendBatchCommits(ctx, tx, j, "git_commits", "git_commit_trailers", jsonTmpPath); err != nil {
+			return err
+		}
 	}
+	rowsInserted(j, "git_commits", insertedCommits)
 
 	l.Info().Msgf("sent batch of %d commits", insertedCommits)
 
@@ -232,6 +387,19 @@ func (w *worker) handleGitCommits(ctx context.Context, j *db.DequeueSyncJobRow)
 		return err
 	}
 
+	// record the synced tips, so the next sync only processes what's new since then
+	if err := w.db.WithTx(tx).DeleteRepoSyncCheckpoints(ctx, j.RepoSyncID); err != nil {
+		return err
+	}
+
+	for _, tip := range tips {
+		if err := w.db.WithTx(tx).UpsertRepoSyncCheckpoint(ctx, db.UpsertRepoSyncCheckpointParams{
+			RepoSyncID: j.RepoSyncID, Ref: tip.ref, CommitHash: tip.id.String(),
+		}); err != nil {
+			return err
+		}
+	}
+
 	if err := w.db.WithTx(tx).SetSyncJobStatus(ctx, db.SetSyncJobStatusParams{Status: "DONE", ID: j.ID}); err != nil {
 		return err
 	}
@@ -2,59 +2,252 @@ package syncer
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
-	"os"
+	"path"
 	"strings"
 	"unicode/utf8"
 
+	"github.com/go-enry/go-enry/v2"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
+	libgit2 "github.com/libgit2/git2go/v33"
 	"github.com/mergestat/mergestat/internal/db"
 	"github.com/mergestat/mergestat/internal/helper"
 	uuid "github.com/satori/go.uuid"
 )
 
-func (w *worker) sendBatchFiles(ctx context.Context, tx pgx.Tx, j *db.DequeueSyncJobRow, batch []*file) error {
-	inputs := make([][]interface{}, 0, len(batch))
-	for _, c := range batch {
-		var repoID uuid.UUID
-		var err error
-		if repoID, err = uuid.FromString(j.RepoID.String()); err != nil {
-			return fmt.Errorf("uuid: %w", err)
+// bounds of the batches of files sent to git_files, a COPY is ended once either of them is reached
+const (
+	filesBatchRows  = 1000     // number of files in a batch
+	filesBatchBytes = 64 << 20 // size of the contents of the files in a batch
+)
+
+// columns of the staging table the files are copied into, in the order of the values of fileSource
+var filesColumns = []string{
+	"repo_id", "ref", "path", "executable", "contents", "contents_omitted_reason", "blob_sha", "size", "language",
+	"is_binary", "is_vendored", "is_generated", "is_documentation", "code_lines", "comment_lines", "blank_lines",
+}
+
+// reasons the contents of a file are not stored in git_files
+const (
+	omittedInvalidUTF8 = "invalid_utf8"
+	omittedTooLarge    = "too_large"
+)
+
+// treeFile is a file of the tree of a commit
+type treeFile struct {
+	id         *libgit2.Oid
+	path       string
+	executable bool
+}
+
+// listFiles returns the files of the tree of the given commit
+func listFiles(repo *libgit2.Repository, id *libgit2.Oid) (_ []treeFile, err error) {
+	var commit *libgit2.Commit
+	if commit, err = repo.LookupCommit(id); err != nil {
+		return nil, err
+	}
+	defer commit.Free()
+
+	var tree *libgit2.Tree
+	if tree, err = commit.Tree(); err != nil {
+		return nil, err
+	}
+	defer tree.Free()
+
+	var files []treeFile
+	err = tree.Walk(func(dir string, entry *libgit2.TreeEntry) error {
+		if entry.Type == libgit2.ObjectBlob {
+			files = append(files, treeFile{id: entry.Id, path: path.Join(dir, entry.Name), executable: entry.Filemode == libgit2.FilemodeBlobExecutable})
+		}
+		return nil
+	})
+	return files, err
+}
+
+// fileSource is a pgx.CopyFromSource streaming the files of a ref into the staging table of git_files. A COPY from
+// it ends once the current batch is full, and the next one resumes from the following file. The size of a file is
+// read from the header of its blob, so that the contents of the files above the max file size are never loaded.
+type fileSource struct {
+	repo        *libgit2.Repository
+	odb         *libgit2.Odb
+	files       []treeFile
+	repoID      uuid.UUID
+	ref         string
+	maxFileSize int64 // size in bytes above which the contents of a file are omitted, no limit if 0
+
+	index       int // index of the next file to stream
+	count, size int // number of files in the current batch, and size of their contents
+	omitted     int // number of files whose contents were omitted, across all batches
+	done        bool
+
+	values []interface{}
+	err    error
+}
+
+// next prepares the source for the next batch
+func (s *fileSource) next() { s.count, s.size = 0, 0 }
+
+func (s *fileSource) Next() bool {
+	if s.done || s.count >= filesBatchRows || s.size >= filesBatchBytes {
+		return false
+	}
+
+	if s.index >= len(s.files) {
+		s.done = true
+		return false
+	}
+
+	var f = s.files[s.index]
+	s.index++
+
+	var size uint64
+	if size, _, s.err = s.odb.ReadHeader(f.id); s.err != nil {
+		return false
+	}
+
+	// the metadata derived from the contents of a file is unknown if they're omitted for being too large
+	var contents, omittedReason, language, isBinary, isGenerated, codeLines, commentLines, blankLines interface{}
+	if s.maxFileSize > 0 && int64(size) > s.maxFileSize {
+		omittedReason = omittedTooLarge
+		s.omitted++
+
+		if lang := enry.GetLanguage(f.path, nil); lang != "" {
+			language = lang
 		}
+	} else {
+		var blob *libgit2.Blob
+		if blob, s.err = s.repo.LookupBlob(f.id); s.err != nil {
+			return false
+		}
+		var raw = blob.Contents()
+		blob.Free()
 
-		var contents interface{}
-		if utf8.ValidString(c.Contents.String) {
-			contents = strings.ReplaceAll(c.Contents.String, "\u0000", "")
+		if utf8.Valid(raw) {
+			contents = strings.ReplaceAll(string(raw), "\u0000", "")
 		} else {
-			contents = nil
+			omittedReason = omittedInvalidUTF8
+		}
+
+		var lang = enry.GetLanguage(f.path, raw)
+		if lang != "" {
+			language = lang
+		}
+
+		// line counts are only relevant for text files
+		var binary = enry.IsBinary(raw)
+		if !binary {
+			var counts = helper.CountLines(lang, raw)
+			codeLines, commentLines, blankLines = counts.Code, counts.Comment, counts.Blank
 		}
-		input := []interface{}{repoID, c.Path.String, c.Executable.Bool, contents}
-		inputs = append(inputs, input)
+		isBinary, isGenerated = binary, enry.IsGenerated(f.path, raw)
+
+		s.size += len(raw)
 	}
 
-	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"git_files"}, []string{"repo_id", "path", "executable", "contents"}, pgx.CopyFromRows(inputs)); err != nil {
-		return fmt.Errorf("tx copy from: %w", err)
+	s.count++
+	s.values = []interface{}{
+		s.repoID, s.ref, f.path, f.executable, contents, omittedReason, f.id.String(), int64(size), language,
+		isBinary, enry.IsVendor(f.path), isGenerated, enry.IsDocumentation(f.path),
+		codeLines, commentLines, blankLines,
 	}
-	return nil
+	return true
 }
 
-type file struct {
-	Path       sql.NullString `json:"path"`
-	Executable sql.NullBool   `json:"executable"`
-	Contents   sql.NullString `json:"contents"`
-}
+func (s *fileSource) Values() ([]interface{}, error) { return s.values, nil }
 
-const selectFiles = `
-SELECT
-	path, executable, contents
-FROM files(?);
+func (s *fileSource) Err() error { return s.err }
+
+// the files are copied into a staging table, from which their contents are upserted into git_blobs, once per blob, and
+// the files inserted into git_file_entries (which the git_files view reads), referencing their blob. The conflicting rows
+// of git_blobs are locked even when they aren't updated, so that the cleanup of orphaned blobs skips them until the files
+// referencing them are committed.
+const (
+	createFilesStagingTable = "CREATE TEMP TABLE _mergestat_git_files (LIKE git_file_entries INCLUDING DEFAULTS, contents TEXT) ON COMMIT DROP;"
+
+	upsertBlobs = `
+INSERT INTO git_blobs (sha, contents, size)
+SELECT DISTINCT ON (blob_sha) blob_sha, contents, size FROM _mergestat_git_files ORDER BY blob_sha, contents IS NULL
+ON CONFLICT (sha) DO UPDATE SET contents = excluded.contents
+WHERE git_blobs.contents IS NULL AND excluded.contents IS NOT NULL;
 `
 
+	insertStagedFiles = `
+INSERT INTO git_file_entries (repo_id, ref, path, executable, contents_omitted_reason, blob_sha, size, language, is_binary,
+	is_vendored, is_generated, is_documentation, code_lines, comment_lines, blank_lines)
+SELECT repo_id, ref, path, executable, contents_omitted_reason, blob_sha, size, language, is_binary,
+	is_vendored, is_generated, is_documentation, code_lines, comment_lines, blank_lines
+FROM _mergestat_git_files;
+`
+
+	truncateFilesStagingTable = "TRUNCATE _mergestat_git_files;"
+)
+
+// sendFiles streams the files of the ref into git_files (and their contents into git_blobs) in batches, and returns
+// the number of files inserted
+func (w *worker) sendFiles(ctx context.Context, tx pgx.Tx, j *db.DequeueSyncJobRow, settings *gitFilesSettings, repo *libgit2.Repository, tip commitTip) (int64, error) {
+	var repoID, err = uuid.FromString(j.RepoID.String())
+	if err != nil {
+		return 0, fmt.Errorf("uuid: %w", err)
+	}
+
+	var files []treeFile
+	if files, err = listFiles(repo, tip.id); err != nil {
+		return 0, fmt.Errorf("list files: %w", err)
+	}
+
+	var odb *libgit2.Odb
+	if odb, err = repo.Odb(); err != nil {
+		return 0, fmt.Errorf("open odb: %w", err)
+	}
+	defer odb.Free()
+
+	var l = w.loggerForJob(j)
+	var source = &fileSource{repo: repo, odb: odb, files: files, repoID: repoID, ref: tip.ref, maxFileSize: settings.MaxFileSize}
+	var total int64
+	for !source.done {
+		source.next()
+
+		if _, err = tx.CopyFrom(ctx, pgx.Identifier{"_mergestat_git_files"}, filesColumns, source); err != nil {
+			return total, fmt.Errorf("tx copy from: %w", err)
+		}
+
+		if _, err = tx.Exec(ctx, upsertBlobs); err != nil {
+			return total, fmt.Errorf("exec upsert blobs: %w", err)
+		}
+
+		var r pgconn.CommandTag
+		if r, err = tx.Exec(ctx, insertStagedFiles); err != nil {
+			return total, fmt.Errorf("exec insert files: %w", err)
+		}
+
+		if _, err = tx.Exec(ctx, truncateFilesStagingTable); err != nil {
+			return total, fmt.Errorf("exec truncate: %w", err)
+		}
+
+		var n = r.RowsAffected()
+
+		total += n
+		rowsInserted(j, "git_files", int(n))
+		l.Info().Msgf("sent batch of %d files from %s", n, tip.ref)
+	}
+
+	if source.omitted > 0 {
+		if err = w.sendBatchLogMessages(ctx, []*syncLog{{
+			Type:            SyncLogTypeInfo,
+			RepoSyncQueueID: j.ID,
+			Message:         fmt.Sprintf("omitted the contents of %d file(s) from %s larger than %d bytes", source.omitted, tip.ref, settings.MaxFileSize),
+		}}); err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
 func (w *worker) handleGitFiles(ctx context.Context, j *db.DequeueSyncJobRow) error {
 	var err error
-	l := w.loggerForJob(j)
 
 	// indicate that we're starting query execution
 	if err := w.sendBatchLogMessages(ctx, []*syncLog{{Type: SyncLogTypeInfo, RepoSyncQueueID: j.ID,
@@ -63,23 +256,26 @@ func (w *worker) handleGitFiles(ctx context.Context, j *db.DequeueSyncJobRow) er
 		return fmt.Errorf("send batch log messages: %w", err)
 	}
 
-	tmpPath, cleanup, err := helper.CreateTempDir(os.Getenv("GIT_CLONE_PATH"), fmt.Sprintf("mergestat-repo-%s-*", j.RepoID.String()))
+	repoPath, release, err := w.checkout(ctx, j)
 	if err != nil {
-		return fmt.Errorf("temp dir: %w", err)
+		return fmt.Errorf("git clone: %w", err)
 	}
-	defer func() {
-		if err := cleanup(); err != nil {
-			l.Err(err).Msgf("error cleaning up repo at: %s, %v", tmpPath, err)
-		}
-	}()
+	defer release()
 
-	if err = w.clone(ctx, tmpPath, j); err != nil {
-		return fmt.Errorf("git clone: %w", err)
+	var repo *libgit2.Repository
+	if repo, err = libgit2.OpenRepository(repoPath); err != nil {
+		return fmt.Errorf("open repo: %w", err)
 	}
+	defer repo.Free()
 
-	files := make([]*file, 0)
-	if err = w.mergestat.SelectContext(ctx, &files, selectFiles, tmpPath, tmpPath); err != nil {
-		return fmt.Errorf("mergestat query files: %w", err)
+	var settings gitFilesSettings
+	if err = parseSettings(j, &settings); err != nil {
+		return err
+	}
+
+	var tips []commitTip
+	if tips, err = resolveRefs(repo, j); err != nil {
+		return fmt.Errorf("resolve refs: %w", err)
 	}
 
 	var tx pgx.Tx
@@ -94,7 +290,7 @@ func (w *worker) handleGitFiles(ctx context.Context, j *db.DequeueSyncJobRow) er
 		}
 	}()
 
-	r, err := tx.Exec(ctx, "DELETE FROM git_files WHERE repo_id = $1;", j.RepoID.String())
+	r, err := tx.Exec(ctx, "DELETE FROM git_file_entries WHERE repo_id = $1;", j.RepoID.String())
 	if err != nil {
 		return fmt.Errorf("exec delete: %w", err)
 	}
@@ -107,18 +303,23 @@ func (w *worker) handleGitFiles(ctx context.Context, j *db.DequeueSyncJobRow) er
 		return err
 	}
 
-	if err := w.sendBatchFiles(ctx, tx, j, files); err != nil {
-		return fmt.Errorf("send batch files: %w", err)
+	if _, err = tx.Exec(ctx, createFilesStagingTable); err != nil {
+		return fmt.Errorf("exec create staging table: %w", err)
 	}
 
-	l.Info().Msgf("sent batch of %d files", len(files))
+	for _, tip := range tips {
+		var n int64
+		if n, err = w.sendFiles(ctx, tx, j, &settings, repo, tip); err != nil {
+			return fmt.Errorf("send files: %w", err)
+		}
 
-	if err := w.sendBatchLogMessages(ctx, []*syncLog{{
-		Type:            SyncLogTypeInfo,
-		RepoSyncQueueID: j.ID,
-		Message:         fmt.Sprintf("inserted %d row(s) into git_files", len(files)),
-	}}); err != nil {
-		return err
+		if err := w.sendBatchLogMessages(ctx, []*syncLog{{
+			Type:            SyncLogTypeInfo,
+			RepoSyncQueueID: j.ID,
+			Message:         fmt.Sprintf("inserted %d row(s) from %s into git_files", n, tip.ref),
+		}}); err != nil {
+			return err
+		}
 	}
 
 	if err := w.db.WithTx(tx).SetSyncJobStatus(ctx, db.SetSyncJobStatusParams{Status: "DONE", ID: j.ID}); err != nil {
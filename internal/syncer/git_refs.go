@@ -5,11 +5,9 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"os"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/mergestat/mergestat/internal/db"
-	"github.com/mergestat/mergestat/internal/helper"
 	uuid "github.com/satori/go.uuid"
 )
 
@@ -86,22 +84,14 @@ func (w *worker) handleGitRefs(ctx context.Context, j *db.DequeueSyncJobRow) err
 		return fmt.Errorf("send batch log messages: %w", err)
 	}
 
-	tmpPath, cleanup, err := helper.CreateTempDir(os.Getenv("GIT_CLONE_PATH"), fmt.Sprintf("mergestat-repo-%s-*", j.RepoID.String()))
+	repoPath, release, err := w.checkout(ctx, j)
 	if err != nil {
-		return fmt.Errorf("temp dir: %w", err)
-	}
-	defer func() {
-		if err = cleanup(); err != nil {
-			l.Err(err).Msgf("error cleaning up repo at: %s, %v", tmpPath, err)
-		}
-	}()
-
-	if err = w.clone(ctx, tmpPath, j); err != nil {
 		return fmt.Errorf("git clone: %w", err)
 	}
+	defer release()
 
 	refs := make([]*ref, 0)
-	if err = w.mergestat.SelectContext(ctx, &refs, selectRefs, tmpPath); err != nil {
+	if err = w.mergestat.SelectContext(ctx, &refs, selectRefs, repoPath); err != nil {
 		return err
 	}
 
@@ -135,6 +125,7 @@ func (w *worker) handleGitRefs(ctx context.Context, j *db.DequeueSyncJobRow) err
 	if err := w.sendBatchGitRefs(ctx, tx, j, refs); err != nil {
 		return err
 	}
+	rowsInserted(j, "git_refs", len(refs))
 
 	l.Info().Msgf("sent batch of %d refs", len(refs))
 
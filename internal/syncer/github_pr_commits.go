@@ -145,6 +145,7 @@ func (w *worker) handleGitHubPRCommits(ctx context.Context, j *db.DequeueSyncJob
 	if err := w.sendBatchGitHubPRCommits(ctx, tx, id, commits); err != nil {
 		return fmt.Errorf("insert pr commits: %w", err)
 	}
+	rowsInserted(j, "github_pull_request_commits", len(commits))
 
 	if err := w.sendBatchLogMessages(ctx, []*syncLog{{
 		Type:            SyncLogTypeInfo,
@@ -155,6 +155,7 @@ func (w *worker) handleGitHubPRReviews(ctx context.Context, j *db.DequeueSyncJob
 	if err := w.sendBatchGitHubPRReviews(ctx, tx, id, reviews); err != nil {
 		return fmt.Errorf("insert pr reviews: %w", err)
 	}
+	rowsInserted(j, "github_pull_request_reviews", len(reviews))
 
 	l.Info().Msgf("retrieved PR reviews: %d", len(reviews))
 
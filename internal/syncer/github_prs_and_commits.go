@@ -17,7 +17,6 @@ import (
 	"github.com/mergestat/mergestat/internal/helper"
 	"github.com/mergestat/mergestat/queries"
 	uuid "github.com/satori/go.uuid"
-	"golang.org/x/oauth2"
 )
 
 func (w *worker) handleGitHubRepoPRsAndCommits(ctx context.Context, j *db.DequeueSyncJobRow) error {
@@ -54,17 +53,13 @@ func (w *worker) handleGitHubRepoPRsAndCommits(ctx context.Context, j *db.Dequeu
 
 	prsToInsert := make([]*githubRepoPR, 0)
 
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: ghToken},
-	)
+	client := helper.NewGitHubClient(ctx, ghToken)
 
-	tc := oauth2.NewClient(ctx, ts)
-	if len(ghToken) <= 0 {
-		tc = nil
+	var settings githubPRSettings
+	if err = parseSettings(j, &settings); err != nil {
+		return err
 	}
 
-	client := github.NewClient(tc)
-
 	var perPage = 50 // match the default used by mergestat-lite
 	if perPageEnv := os.Getenv("GITHUB_PER_PAGE"); perPageEnv != "" {
 		if perPage, err = strconv.Atoi(perPageEnv); err != nil {
@@ -72,19 +67,37 @@ func (w *worker) handleGitHubRepoPRsAndCommits(ctx context.Context, j *db.Dequeu
 		}
 	}
 
+	if settings.PerPage > 0 {
+		perPage = settings.PerPage
+	}
+
+	// with a lookback window, only the pull requests updated within it are fetched (most recently updated first)
+	// and replaced in the database, the ones updated before are left as they were synced previously
+	var since time.Time
+	var listOpt = &github.PullRequestListOptions{State: "all"}
+	if settings.LookbackDays > 0 {
+		since = time.Now().AddDate(0, 0, -settings.LookbackDays)
+		listOpt.Sort, listOpt.Direction = "updated", "desc"
+	}
+
 	opt := &github.ListOptions{PerPage: perPage}
 	allPRs := make([]*github.PullRequest, 0)
 
 	for {
-		page, resp, err := client.PullRequests.List(ctx, repoOwner, repoName, &github.PullRequestListOptions{
-			State:       "all",
-			ListOptions: *opt,
-		})
+		listOpt.ListOptions = *opt
+		page, resp, err := client.PullRequests.List(ctx, repoOwner, repoName, listOpt)
 		if err != nil {
 			return err
 		}
 
-		allPRs = append(allPRs, page...)
+		var outside bool
+		for _, pr := range page {
+			if !since.IsZero() && pr.GetUpdatedAt().Before(since) {
+				outside = true
+				break
+			}
+			allPRs = append(allPRs, pr)
+		}
 
 		// TODO(patrickdevivo) add additional context to this log message
 		// also send to database?
@@ -92,7 +105,7 @@ func (w *worker) handleGitHubRepoPRsAndCommits(ctx context.Context, j *db.Dequeu
 
 		helper.RestRatelimitHandler(ctx, resp, w.logger, queries.NewQuerier(w.db), true)
 
-		if resp.NextPage == 0 {
+		if resp.NextPage == 0 || outside {
 			break
 		}
 		opt.Page = resp.NextPage
@@ -240,8 +253,17 @@ func (w *worker) handleGitHubRepoPRsAndCommits(ctx context.Context, j *db.Dequeu
 		}
 	}()
 
-	// Delete all rows from github_pull_requests and github_pull_request_commits
-	r, err := tx.Exec(ctx, "DELETE FROM github_pull_requests WHERE repo_id = $1;", j.RepoID.String())
+	// Delete all rows from github_pull_requests and github_pull_request_commits,
+	// or only those of the pull requests that were fetched if there's a lookback window
+	var numbers []int
+	if !since.IsZero() {
+		numbers = make([]int, 0, len(allPRs))
+		for _, pr := range allPRs {
+			numbers = append(numbers, pr.GetNumber())
+		}
+	}
+
+	r, err := tx.Exec(ctx, "DELETE FROM github_pull_requests WHERE repo_id = $1 AND ($2::INTEGER[] IS NULL OR number = ANY($2));", j.RepoID.String(), numbers)
 	if err != nil {
 		return fmt.Errorf("delete rows: %w", err)
 	}
@@ -254,7 +276,7 @@ func (w *worker) handleGitHubRepoPRsAndCommits(ctx context.Context, j *db.Dequeu
 		return err
 	}
 
-	r, err = tx.Exec(ctx, "DELETE FROM github_pull_request_commits WHERE repo_id = $1;", j.RepoID.String())
+	r, err = tx.Exec(ctx, "DELETE FROM github_pull_request_commits WHERE repo_id = $1 AND ($2::INTEGER[] IS NULL OR pr_number = ANY($2));", j.RepoID.String(), numbers)
 	if err != nil {
 		return fmt.Errorf("exec delete: %w", err)
 	}
@@ -271,6 +293,7 @@ func (w *worker) handleGitHubRepoPRsAndCommits(ctx context.Context, j *db.Dequeu
 	if err := w.sendBatchGitHubRepoPRs(ctx, tx, id, prsToInsert); err != nil {
 		return fmt.Errorf("insert PRs: %w", err)
 	}
+	rowsInserted(j, "github_pull_requests", len(prsToInsert))
 
 	l.Info().Msgf("inserted repo PRs: %d", len(prsToInsert))
 
@@ -285,6 +308,7 @@ func (w *worker) handleGitHubRepoPRsAndCommits(ctx context.Context, j *db.Dequeu
 	if err := w.sendBatchGitHubPRCommits(ctx, tx, id, allPRCommitsToInsert); err != nil {
 		return fmt.Errorf("insert pr commits: %w", err)
 	}
+	rowsInserted(j, "github_pull_request_commits", len(allPRCommitsToInsert))
 
 	if err := w.sendBatchLogMessages(ctx, []*syncLog{{
 		Type:            SyncLogTypeInfo,
@@ -180,6 +180,7 @@ func (w *worker) handleGitHubRepoIssues(ctx context.Context, j *db.DequeueSyncJo
 	if err := w.sendBatchGitHubRepoIssues(ctx, tx, id, issues); err != nil {
 		return fmt.Errorf("insert issues: %w", err)
 	}
+	rowsInserted(j, "github_issues", len(issues))
 
 	l.Info().Msgf("inserted repo issues: %d", len(issues))
 
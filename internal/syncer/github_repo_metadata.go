@@ -14,7 +14,6 @@ import (
 	"github.com/mergestat/mergestat/internal/db"
 	"github.com/mergestat/mergestat/internal/helper"
 	"github.com/mergestat/mergestat/queries"
-	"golang.org/x/oauth2"
 )
 
 func (w *worker) handleGitHubRepoMetadata(ctx context.Context, j *db.DequeueSyncJobRow) error {
@@ -226,16 +225,7 @@ func (w *worker) getRepositoryInfo(ctx context.Context, ghToken string, currentR
 		resp          *github.Response
 	)
 
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: ghToken},
-	)
-
-	tc := oauth2.NewClient(ctx, ts)
-	if len(ghToken) <= 0 {
-		tc = nil
-	}
-
-	client := github.NewClient(tc)
+	client := helper.NewGitHubClient(ctx, ghToken)
 
 	if len(ghToken) > 0 {
 		// we check the rate limit before any call to the GitHub API
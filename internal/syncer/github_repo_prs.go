@@ -231,6 +231,7 @@ func (w *worker) handleGitHubRepoPRs(ctx context.Context, j *db.DequeueSyncJobRo
 	if err := w.sendBatchGitHubRepoPRs(ctx, tx, id, prs); err != nil {
 		return fmt.Errorf("insert PRs: %w", err)
 	}
+	rowsInserted(j, "github_pull_requests", len(prs))
 
 	l.Info().Msgf("inserted repo PRs: %d", len(prs))
 
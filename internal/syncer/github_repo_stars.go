@@ -141,6 +141,7 @@ func (w *worker) handleGitHubRepoStars(ctx context.Context, j *db.DequeueSyncJob
 	if err := w.sendBatchGitHubRepoStars(ctx, tx, id, stars); err != nil {
 		return fmt.Errorf("batch insert stars: %w", err)
 	}
+	rowsInserted(j, "github_stargazers", len(stars))
 
 	if err := w.sendBatchLogMessages(ctx, []*syncLog{{
 		Type:            SyncLogTypeInfo,
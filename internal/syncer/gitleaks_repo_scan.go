@@ -6,11 +6,9 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/mergestat/mergestat/internal/db"
-	"github.com/mergestat/mergestat/internal/helper"
 )
 
 // handleGitleaksRepoScan executes `gitleaks detect {git-repo} -f json` for a repo
@@ -18,19 +16,11 @@ import (
 func (w *worker) handleGitleaksRepoScan(ctx context.Context, j *db.DequeueSyncJobRow) error {
 	l := w.loggerForJob(j)
 
-	tmpPath, cleanup, err := helper.CreateTempDir(os.Getenv("GIT_CLONE_PATH"), fmt.Sprintf("mergestat-repo-%s-*", j.RepoID.String()))
+	repoPath, release, err := w.checkoutWorktree(ctx, j)
 	if err != nil {
-		return fmt.Errorf("temp dir: %w", err)
-	}
-	defer func() {
-		if err := cleanup(); err != nil {
-			l.Err(err).Msgf("error cleaning up repo at: %s, %v", tmpPath, err)
-		}
-	}()
-
-	if err = w.clone(ctx, tmpPath, j); err != nil {
 		return fmt.Errorf("git clone: %w", err)
 	}
+	defer release()
 
 	// indicate that we're starting a gitleaks scan
 	if err := w.sendBatchLogMessages(ctx, []*syncLog{{Type: SyncLogTypeInfo, RepoSyncQueueID: j.ID,
@@ -39,8 +29,16 @@ func (w *worker) handleGitleaksRepoScan(ctx context.Context, j *db.DequeueSyncJo
 		return fmt.Errorf("send batch log messages: %w", err)
 	}
 
-	cmd := exec.CommandContext(ctx, "gitleaks", "detect", "-f", "json", "-r", "_mergestat_gitleaks_scan_results.json", "--exit-code", "0")
-	cmd.Dir = tmpPath
+	// the report is written outside the working tree, so that it isn't picked up by the scan
+	report, err := os.CreateTemp(os.Getenv("GIT_CLONE_PATH"), "_mergestat_gitleaks_scan_results-*.json")
+	if err != nil {
+		return fmt.Errorf("temp file: %w", err)
+	}
+	_ = report.Close()
+	defer os.Remove(report.Name())
+
+	cmd := exec.CommandContext(ctx, "gitleaks", "detect", "-f", "json", "-r", report.Name(), "--exit-code", "0")
+	cmd.Dir = repoPath
 
 	if err = cmd.Run(); err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
@@ -50,7 +48,7 @@ func (w *worker) handleGitleaksRepoScan(ctx context.Context, j *db.DequeueSyncJo
 	}
 
 	var output []byte
-	if output, err = os.ReadFile(filepath.Join(tmpPath, "_mergestat_gitleaks_scan_results.json")); err != nil {
+	if output, err = os.ReadFile(report.Name()); err != nil {
 		return fmt.Errorf("reading gitleaks scan results: %w", err)
 	}
 
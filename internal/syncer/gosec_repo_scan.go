@@ -6,13 +6,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
 	"os/exec"
 	"strings"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/mergestat/mergestat/internal/db"
-	"github.com/mergestat/mergestat/internal/helper"
 )
 
 // gosecIssue represents an issue identified by gosec.
@@ -37,19 +35,11 @@ type gosecIssue struct {
 func (w *worker) handleGosecRepoScan(ctx context.Context, j *db.DequeueSyncJobRow) error {
 	l := w.loggerForJob(j)
 
-	tmpPath, cleanup, err := helper.CreateTempDir(os.Getenv("GIT_CLONE_PATH"), fmt.Sprintf("mergestat-repo-%s-*", j.RepoID.String()))
+	repoPath, release, err := w.checkoutWorktree(ctx, j)
 	if err != nil {
-		return fmt.Errorf("temp dir: %w", err)
-	}
-	defer func() {
-		if err := cleanup(); err != nil {
-			l.Err(err).Msgf("error cleaning up repo at: %s, %v", tmpPath, err)
-		}
-	}()
-
-	if err = w.clone(ctx, tmpPath, j); err != nil {
 		return fmt.Errorf("git clone: %w", err)
 	}
+	defer release()
 
 	// indicate that we're starting a gitleaks scan
 	if err := w.sendBatchLogMessages(ctx, []*syncLog{{Type: SyncLogTypeInfo, RepoSyncQueueID: j.ID,
@@ -60,7 +50,7 @@ func (w *worker) handleGosecRepoScan(ctx context.Context, j *db.DequeueSyncJobRo
 
 	var stdout, stderr bytes.Buffer
 	cmd := exec.CommandContext(ctx, "gosec", "-no-fail", "-fmt", "json", ".")
-	cmd.Dir = tmpPath
+	cmd.Dir = repoPath
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
@@ -76,9 +66,9 @@ func (w *worker) handleGosecRepoScan(ctx context.Context, j *db.DequeueSyncJobRo
 		return fmt.Errorf("failed to parse gosec output: %w", err)
 	}
 
-	// trim filepath in gosec response and drop the tmpPath prefix
+	// trim filepath in gosec response and drop the repoPath prefix
 	for _, issue := range resp.Issues {
-		issue.File = strings.TrimPrefix(issue.File, tmpPath)
+		issue.File = strings.TrimPrefix(issue.File, repoPath)
 	}
 
 	stdout.Reset() // reuse buffer
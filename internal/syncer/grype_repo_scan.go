@@ -6,29 +6,19 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"strings"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/mergestat/mergestat/internal/db"
-	"github.com/mergestat/mergestat/internal/helper"
 )
 
 func (w *worker) handleGrypeRepoScan(ctx context.Context, j *db.DequeueSyncJobRow) error {
 	l := w.loggerForJob(j)
 
-	tmpPath, cleanup, err := helper.CreateTempDir(os.Getenv("GIT_CLONE_PATH"), fmt.Sprintf("mergestat-repo-%s-*", j.RepoID.String()))
+	repoPath, release, err := w.checkoutWorktree(ctx, j)
 	if err != nil {
-		return fmt.Errorf("temp dir: %w", err)
-	}
-	defer func() {
-		if err := cleanup(); err != nil {
-			l.Err(err).Msgf("error cleaning up repo at: %s, %v", tmpPath, err)
-		}
-	}()
-
-	if err = w.clone(ctx, tmpPath, j); err != nil {
 		return fmt.Errorf("git clone: %w", err)
 	}
+	defer release()
 
 	// indicate that we're starting a grype scan
 	if err := w.sendBatchLogMessages(ctx, []*syncLog{{Type: SyncLogTypeInfo, RepoSyncQueueID: j.ID,
@@ -37,9 +27,16 @@ func (w *worker) handleGrypeRepoScan(ctx context.Context, j *db.DequeueSyncJobRo
 		return fmt.Errorf("send batch log messages: %w", err)
 	}
 
-	var jsonFile = "_mergestat_grype_scan_results.json"
-	cmd := exec.CommandContext(ctx, "grype", ".", "-o", "json", "--file", jsonFile)
-	cmd.Dir = tmpPath
+	// the report is written outside the working tree, so that it isn't picked up by the scan
+	report, err := os.CreateTemp(os.Getenv("GIT_CLONE_PATH"), "_mergestat_grype_scan_results-*.json")
+	if err != nil {
+		return fmt.Errorf("temp file: %w", err)
+	}
+	_ = report.Close()
+	defer os.Remove(report.Name())
+
+	cmd := exec.CommandContext(ctx, "grype", ".", "-o", "json", "--file", report.Name())
+	cmd.Dir = repoPath
 
 	if err = cmd.Run(); err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
@@ -49,8 +46,7 @@ func (w *worker) handleGrypeRepoScan(ctx context.Context, j *db.DequeueSyncJobRo
 	}
 
 	var output []byte
-	jsonFile = strings.Join([]string{tmpPath, jsonFile}, "/")
-	if output, err = os.ReadFile(jsonFile); err != nil {
+	if output, err = os.ReadFile(report.Name()); err != nil {
 		return fmt.Errorf("reading grype scan results: %w", err)
 	}
 
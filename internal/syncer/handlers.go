@@ -53,13 +53,17 @@ func (w *worker) loggerForJob(j *db.DequeueSyncJobRow) *zerolog.Logger {
 	return &l
 }
 
-// startKeepAlives sets the latest_keep_alive timestamp on a job every interval
-func (w *worker) startKeepAlives(j *db.DequeueSyncJobRow, interval time.Duration) func() {
+// startKeepAlives sets the latest_keep_alive timestamp on a job every interval.
+// If the cancellation of the job was requested (see mergestat.cancel_repo_sync_job), it calls cancelJob.
+func (w *worker) startKeepAlives(j *db.DequeueSyncJobRow, interval time.Duration, cancelJob func()) func() {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	setKeepAlive := func() {
-		if err := w.db.SetLatestKeepAliveForJob(ctx, j.ID); err != nil {
+		if cancelRequested, err := w.db.SetLatestKeepAliveForJob(ctx, j.ID); err != nil {
 			w.logger.Err(err).Msgf("could not set latest keep alive for job: %d", j.ID)
+		} else if cancelRequested {
+			w.logger.Info().Msgf("cancellation requested for job: %d", j.ID)
+			cancelJob()
 		} else {
 			w.logger.Info().Msgf("sent keep alive for job: %d", j.ID)
 		}
@@ -0,0 +1,96 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mergestat/sqlq"
+)
+
+// channel notified by mergestat.notify_repo_sync_queue() whenever a queued job is enqueued in sqlq
+const queueChannel = "mergestat_repo_sync_queue"
+
+// listen waits for notifications of queued jobs until the context is canceled, waking up the worker for each of them.
+// If the connection is lost, it reconnects after a delay, with jobs being picked up by the polling of the sqlq worker in the meantime.
+func (w *worker) listen(ctx context.Context) {
+	for {
+		if err := w.waitForNotifications(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			w.logger.Warn().AnErr("error", err).Msg("stopped listening for queued jobs, falling back to polling")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
+
+func (w *worker) waitForNotifications(ctx context.Context) error {
+	conn, err := w.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the connection is taken out of the pool, as it can't be used for anything else while listening
+	var listener = conn.Hijack()
+	defer listener.Close(context.Background())
+
+	if _, err = listener.Exec(ctx, "LISTEN "+queueChannel); err != nil {
+		return err
+	}
+
+	w.logger.Info().Msgf("listening for queued jobs on channel: %s", queueChannel)
+
+	for {
+		if _, err = listener.WaitForNotification(ctx); err != nil {
+			return err
+		}
+		w.wakeup(ctx)
+	}
+}
+
+// wakeup dequeues a sqlq job of the sync types of the worker right away, rather than waiting for the next poll of
+// the sqlq worker, and executes it in the background. Nothing is dequeued if all the slots of the worker are taken,
+// the slots being shared with the jobs of the sqlq worker (see execute), so that the worker never runs more jobs
+// than its concurrency.
+func (w *worker) wakeup(ctx context.Context) {
+	select {
+	case w.slots <- struct{}{}:
+	default:
+		return
+	}
+
+	job, err := sqlq.Dequeue(w.upstream, nil, sqlq.WithTypeName(w.jobTypes()))
+	if err != nil || job == nil {
+		if err != nil {
+			w.logger.Err(err).Msgf("failed to dequeue job: %v", err)
+		}
+		<-w.slots
+		return // else the job was picked up by another worker already
+	}
+
+	w.inflight.Add(1)
+	go func() {
+		defer w.inflight.Done()
+		defer func() { <-w.slots }()
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		job = sqlq.AttachPinger(w.upstream, job)
+		go job.SendKeepAlive(ctx, job.KeepAlive-2*time.Second) //nolint:errcheck
+
+		var err error
+		if err = w.process(ctx, job); err != nil {
+			err = sqlq.Error(w.upstream, job, err)
+		} else {
+			err = sqlq.Success(w.upstream, job)
+		}
+
+		if err != nil {
+			w.logger.Err(err).Msgf("failed to complete sqlq job %s: %v", job.ID, err)
+		}
+	}()
+}
@@ -0,0 +1,132 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/mergestat/mergestat/internal/db"
+	"github.com/mergestat/mergestat/internal/metrics"
+	"github.com/mergestat/sqlq"
+	"github.com/mergestat/sqlq/runtime/embed"
+)
+
+// jobTypePrefix prefixes the sync type in the type name of the sqlq jobs executing syncs (see mergestat.enqueue_repo_sync_job)
+const jobTypePrefix = "repos/sync/"
+
+// RegisterHandlers registers the handler executing syncs with the sqlq worker, for each of the sync types this worker can run.
+// It must be called before the sqlq worker is started.
+func (w *worker) RegisterHandlers(sqlqWorker *embed.Worker) error {
+	for _, syncType := range w.syncTypes {
+		if err := sqlqWorker.Register(jobTypePrefix+syncType, sqlq.HandlerFunc(w.execute)); err != nil {
+			return fmt.Errorf("register handler for %s: %w", syncType, err)
+		}
+	}
+	return nil
+}
+
+// jobTypes returns the type names of the sqlq jobs executing the syncs this worker can run
+func (w *worker) jobTypes() []string {
+	var types = make([]string, 0, len(w.syncTypes))
+	for _, syncType := range w.syncTypes {
+		types = append(types, jobTypePrefix+syncType)
+	}
+	return types
+}
+
+// execute processes a job dequeued by the sqlq worker, once a slot of the worker is free. The sqlq worker dequeues
+// jobs regardless of the ones started by wakeup, so the job may have to wait for one of them to be done; it's kept
+// alive in the meantime.
+func (w *worker) execute(ctx context.Context, job *sqlq.Job) error {
+	go job.SendKeepAlive(ctx, job.KeepAlive-2*time.Second) //nolint:errcheck
+
+	select {
+	case w.slots <- struct{}{}:
+		defer func() { <-w.slots }()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return w.process(ctx, job)
+}
+
+// process executes the repo sync job carried by the sqlq job, recording its status and logs in mergestat.repo_sync_queue
+// and mergestat.repo_sync_logs. The caller keeps the sqlq job alive while it runs.
+func (w *worker) process(ctx context.Context, job *sqlq.Job) error {
+	var params struct{ ID int64 }
+	if err := json.Unmarshal(job.Parameters, &params); err != nil {
+		return fmt.Errorf("%w: failed to unmarshal params: %v", sqlq.ErrSkipRetry, err)
+	}
+
+	j, err := w.db.DequeueSyncJob(ctx, db.DequeueSyncJobParams{WorkerID: w.id, ID: params.ID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// the job isn't queued anymore, e.g. it was canceled or timed out in the meantime
+			w.logger.Info().Msgf("sync job %d is not queued anymore, skipping it", params.ID)
+			return nil
+		}
+		return fmt.Errorf("failed to dequeue sync job %d: %w", params.ID, err)
+	}
+
+	w.loggerForJob(&j).Info().Msg("dequeued job")
+	metrics.JobsStarted.WithLabelValues(j.SyncType).Inc()
+
+	var started = time.Now()
+	err = w.handle(ctx, &j)
+	observe(&j, started, err)
+
+	if err == nil {
+		return nil
+	}
+
+	switch {
+	case errors.Is(err, errSyncCanceled):
+		if err := w.db.InsertSyncJobLog(context.TODO(), db.InsertSyncJobLogParams{
+			LogType:         string(SyncLogTypeWarn),
+			Message:         fmt.Sprintf("sync %s for repo %s was canceled", j.SyncType, j.Repo),
+			RepoSyncQueueID: j.ID,
+		}); err != nil {
+			w.logger.Err(err).Msgf("error sending log message: %v", err)
+		}
+
+		if err := w.db.SetSyncJobStatus(context.TODO(), db.SetSyncJobStatusParams{
+			Status: "CANCELED",
+			ID:     j.ID,
+		}); err != nil {
+			w.logger.Err(err).Msgf("error marking sync job as canceled: %v", err)
+		}
+	case errors.Is(err, errSyncTimedOut):
+		if err := w.db.InsertSyncJobLog(context.TODO(), db.InsertSyncJobLogParams{
+			LogType:         string(SyncLogTypeError),
+			Message:         fmt.Sprintf("%v, timing out", err),
+			RepoSyncQueueID: j.ID,
+		}); err != nil {
+			w.logger.Err(err).Msgf("error sending log error message: %v", err)
+		}
+
+		if err := w.db.SetSyncJobStatus(context.TODO(), db.SetSyncJobStatusParams{
+			Status: "FAILED",
+			ID:     j.ID,
+		}); err != nil {
+			w.logger.Err(err).Msgf("error marking sync job as failed: %v", err)
+		}
+	case errors.Is(err, context.Canceled):
+		// the worker is shutting down, queue the job again for another worker to pick it up
+		if _, err := w.db.RequeueSyncJob(context.TODO(), j.ID); err != nil {
+			w.logger.Err(err).Msgf("error marking sync job as queued: %v", err)
+		}
+	default:
+		w.logger.Warn().AnErr("error", err).Msgf("error handling job: %v", j)
+
+		// retry the job if the error is transient, else mark it as failed
+		if err := w.fail(context.TODO(), &j, err); err != nil {
+			w.logger.Err(err).Msgf("error marking sync job as failed: %v", err)
+		}
+	}
+
+	// sqlq never retries the job itself, a retry is a new sqlq job (see worker.fail)
+	return fmt.Errorf("%w: %v", sqlq.ErrSkipRetry, err)
+}
@@ -0,0 +1,132 @@
+package syncer
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	libgit2 "github.com/libgit2/git2go/v33"
+	"github.com/mergestat/mergestat/internal/db"
+)
+
+// commitTip is the commit a ref points to at the time of the sync
+type commitTip struct {
+	ref string // fully qualified name of the ref upstream, e.g. refs/heads/main
+	id  *libgit2.Oid
+}
+
+// resolveRefs returns the refs a git sync should process. These are the refs matching the "refs" setting
+// of the sync (a list of branch / tag names or globs, e.g. ["main", "release/*"]), else the ref configured
+// on the repo, else the default branch of the repo (HEAD).
+func resolveRefs(repo *libgit2.Repository, j *db.DequeueSyncJobRow) ([]commitTip, error) {
+	var settings gitSettings
+	if err := parseSettings(j, &settings); err != nil {
+		return nil, err
+	}
+
+	var patterns = settings.Refs
+	if len(patterns) == 0 && j.Ref.Valid && j.Ref.String != "" {
+		patterns = []string{j.Ref.String}
+	}
+
+	if len(patterns) == 0 {
+		head, err := repo.Head()
+		if err != nil {
+			return nil, err
+		}
+		defer head.Free()
+
+		return []commitTip{{ref: head.Name(), id: head.Target()}}, nil
+	}
+
+	var candidates, err = upstreamRefs(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var tips []commitTip
+	var seen = make(map[string]struct{})
+	for _, pattern := range patterns {
+		for _, candidate := range candidates {
+			if _, ok := seen[candidate.ref]; ok {
+				continue
+			}
+
+			if ok, err := matchRef(pattern, candidate.ref); err != nil {
+				return nil, fmt.Errorf("invalid ref pattern %q: %w", pattern, err)
+			} else if ok {
+				tips = append(tips, candidate)
+				seen[candidate.ref] = struct{}{}
+			}
+		}
+	}
+
+	if len(tips) == 0 {
+		return nil, fmt.Errorf("no refs matching: %s", strings.Join(patterns, ", "))
+	}
+
+	return tips, nil
+}
+
+// matchRef reports whether the fully qualified ref matches the pattern. Patterns that aren't
+// fully qualified (e.g. "main" or "release/*") match both branches and tags of that name.
+func matchRef(pattern, ref string) (bool, error) {
+	if strings.HasPrefix(pattern, "refs/") {
+		return path.Match(pattern, ref)
+	}
+
+	for _, prefix := range []string{"refs/heads/", "refs/tags/"} {
+		if ok, err := path.Match(prefix+pattern, ref); err != nil || ok {
+			return ok, err
+		}
+	}
+
+	return false, nil
+}
+
+// upstreamRefs lists the branches and tags of the remote the repo was cloned from, sorted by name.
+// Remote-tracking branches (refs/remotes/origin/x) are reported by their upstream name (refs/heads/x).
+func upstreamRefs(repo *libgit2.Repository) ([]commitTip, error) {
+	iter, err := repo.NewReferenceIterator()
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Free()
+
+	var refs []commitTip
+	for {
+		ref, err := iter.Next()
+		if err != nil {
+			if libgit2.IsErrorCode(err, libgit2.ErrorCodeIterOver) {
+				break
+			}
+			return nil, err
+		}
+
+		var name string
+		switch n := ref.Name(); {
+		case strings.HasPrefix(n, "refs/remotes/origin/") && n != "refs/remotes/origin/HEAD":
+			name = "refs/heads/" + strings.TrimPrefix(n, "refs/remotes/origin/")
+		case strings.HasPrefix(n, "refs/tags/"):
+			name = n
+		default:
+			ref.Free()
+			continue
+		}
+
+		// annotated tags point to a tag object, which needs to be peeled to the commit it's tagging
+		obj, err := ref.Peel(libgit2.ObjectCommit)
+		ref.Free()
+		if err != nil {
+			continue // not a commit (e.g. a tag of a tree or blob)
+		}
+
+		refs = append(refs, commitTip{ref: name, id: obj.Id()})
+		obj.Free()
+	}
+
+	sort.Slice(refs, func(i, k int) bool { return refs[i].ref < refs[k].ref })
+
+	return refs, nil
+}
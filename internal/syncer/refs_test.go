@@ -0,0 +1,41 @@
+package syncer
+
+import "testing"
+
+func TestMatchRef(t *testing.T) {
+	type testArgs struct {
+		description string
+		pattern     string
+		ref         string
+		want        bool
+		wantErr     bool
+	}
+
+	tests := []testArgs{
+		{description: "branch name", pattern: "main", ref: "refs/heads/main", want: true},
+		{description: "tag name", pattern: "v1.0.0", ref: "refs/tags/v1.0.0", want: true},
+		{description: "different name", pattern: "main", ref: "refs/heads/master", want: false},
+		{description: "name is not a prefix", pattern: "main", ref: "refs/heads/main-2", want: false},
+		{description: "glob of branches", pattern: "release/*", ref: "refs/heads/release/1.2", want: true},
+		{description: "glob of tags", pattern: "v1.*", ref: "refs/tags/v1.2.0", want: true},
+		{description: "glob doesn't match nested names", pattern: "release/*", ref: "refs/heads/release/1.2/rc", want: false},
+		{description: "remote-tracking branches aren't matched by name", pattern: "main", ref: "refs/remotes/origin/main", want: false},
+		{description: "fully qualified branch", pattern: "refs/heads/main", ref: "refs/heads/main", want: true},
+		{description: "fully qualified branch doesn't match tag", pattern: "refs/heads/v1.0.0", ref: "refs/tags/v1.0.0", want: false},
+		{description: "fully qualified glob", pattern: "refs/tags/*", ref: "refs/tags/v1.0.0", want: true},
+		{description: "invalid glob", pattern: "release/[", ref: "refs/heads/release/1.2", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			got, err := matchRef(test.pattern, test.ref)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("matchRef() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if got != test.want {
+				t.Fatalf("matchRef(%q, %q) = %v, want %v", test.pattern, test.ref, got, test.want)
+			}
+		})
+	}
+}
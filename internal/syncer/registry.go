@@ -0,0 +1,92 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mergestat/mergestat/internal/db"
+	"github.com/mergestat/mergestat/pkg/registry"
+	"github.com/rs/zerolog"
+)
+
+// register the built-in sync types
+func init() {
+	var git = registry.Executable("git")
+
+	registry.Register(syncTypeGitCommits, builtin((*worker).handleGitCommits), registry.CapabilityClone)
+	registry.Register(syncTypeGitFiles, builtin((*worker).handleGitFiles), registry.CapabilityClone)
+	registry.Register(syncTypeGitCommitStats, builtin((*worker).handleGitCommitStats), registry.CapabilityClone)
+	registry.Register(syncTypeGitRefs, builtin((*worker).handleGitRefs), registry.CapabilityClone)
+	registry.Register(syncTypeGitBlame, builtin((*worker).handleGitBlame), registry.CapabilityClone, git)
+	registry.Register(syncTypeGitRemotes, builtin((*worker).handleGitRemotes))
+	registry.Register(syncTypeGitHubRepoMetadata, builtin((*worker).handleGitHubRepoMetadata), registry.CapabilityGitHubToken)
+	registry.Register(syncTypeGitHubRepoPRs, builtin((*worker).handleGitHubRepoPRs), registry.CapabilityGitHubToken)
+	registry.Register(syncTypeGitHubRepoIssues, builtin((*worker).handleGitHubRepoIssues), registry.CapabilityGitHubToken)
+	registry.Register(syncTypeGitHubRepoStars, builtin((*worker).handleGitHubRepoStars), registry.CapabilityGitHubToken)
+	registry.Register(syncTypeGitHubPRReviews, builtin((*worker).handleGitHubPRReviews), registry.CapabilityGitHubToken)
+	registry.Register(syncTypeGitHubPRCommits, builtin((*worker).handleGitHubPRCommits), registry.CapabilityGitHubToken)
+	registry.Register(syncTypeGitHubPRsAndCommits, builtin((*worker).handleGitHubRepoPRsAndCommits), registry.CapabilityGitHubToken)
+	registry.Register(syncTypeGitHubActions, builtin((*worker).handleGithubActions), registry.CapabilityGitHubToken)
+	registry.Register(syncTypeTrivyRepoScan, builtin((*worker).handleTrivyRepoScan), registry.Executable("trivy"))
+	registry.Register(syncTypeSyftRepoScan, builtin((*worker).handleSyftRepoScan), registry.CapabilityClone, git, registry.Executable("syft"))
+	registry.Register(syncTypeGitleaksRepoScan, builtin((*worker).handleGitleaksRepoScan), registry.CapabilityClone, git, registry.Executable("gitleaks"))
+	registry.Register(syncTypeYelpDetectSecretsRepoScan, builtin((*worker).handleYelpDetectSecretsRepoScan), registry.CapabilityClone, git, registry.Executable("detect-secrets"))
+	registry.Register(syncTypeGosecRepoScan, builtin((*worker).handleGosecRepoScan), registry.CapabilityClone, git, registry.Executable("gosec"))
+	registry.Register(syncTypeOSSFScorecardRepoScan, builtin((*worker).handleOSSFScorecardScan), registry.CapabilityGitHubToken, registry.Executable("scorecard"))
+	registry.Register(syncTypeGrypeScan, builtin((*worker).handleGrypeRepoScan), registry.CapabilityClone, git, registry.Executable("grype"))
+}
+
+// builtin adapts a handler method of the worker into a registry.SyncHandler
+type builtin func(w *worker, ctx context.Context, j *db.DequeueSyncJobRow) error
+
+func (fn builtin) Handle(ctx context.Context, rt registry.Runtime, _ *registry.Job) error {
+	var r = rt.(*jobRuntime)
+	return fn(r.worker, ctx, r.job)
+}
+
+// jobRuntime implements registry.Runtime for a job dequeued by the worker
+type jobRuntime struct {
+	worker *worker
+	job    *db.DequeueSyncJobRow
+}
+
+func (r *jobRuntime) Logger() *zerolog.Logger { return r.worker.loggerForJob(r.job) }
+
+func (r *jobRuntime) Pool() *pgxpool.Pool { return r.worker.pool }
+
+func (r *jobRuntime) Log(ctx context.Context, typ registry.LogType, message string) error {
+	return r.worker.sendBatchLogMessages(ctx, []*syncLog{{Type: syncLogType(typ), RepoSyncQueueID: r.job.ID, Message: message}})
+}
+
+func (r *jobRuntime) Checkout(ctx context.Context) (string, func(), error) {
+	return r.worker.checkoutWorktree(ctx, r.job)
+}
+
+func (r *jobRuntime) Credentials(ctx context.Context) (string, string, error) {
+	return r.worker.fetchCredentials(ctx, r.job)
+}
+
+func (r *jobRuntime) Done(ctx context.Context, tx pgx.Tx) error {
+	return r.worker.db.WithTx(tx).SetSyncJobStatus(ctx, db.SetSyncJobStatusParams{Status: "DONE", ID: r.job.ID})
+}
+
+// registryJob converts a dequeued job into the representation passed to registered handlers
+func registryJob(j *db.DequeueSyncJobRow) *registry.Job {
+	var job = &registry.Job{
+		ID:       j.ID,
+		SyncID:   j.RepoSyncID,
+		SyncType: j.SyncType,
+		RepoID:   j.RepoID,
+		Repo:     j.Repo,
+		Ref:      j.Ref.String,
+		Attempt:  int(j.Attempt),
+	}
+
+	if len(j.Settings.Bytes) > 0 {
+		job.Settings = json.RawMessage(j.Settings.Bytes)
+	}
+
+	return job
+}
@@ -0,0 +1,114 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/mergestat/mergestat/internal/db"
+	"github.com/mergestat/mergestat/pkg/registry"
+)
+
+// classes of transient errors a sync type can be configured to retry (see mergestat.repo_sync_types.retry_on)
+const (
+	retryOnNetwork  = "network"
+	retryOnDatabase = "database"
+	retryOnGitHub   = "github"
+)
+
+// errorClass returns the class of the error if it's known to be transient, else an empty string
+func errorClass(err error) string {
+	var pgErr *pgconn.PgError
+	var ghErr *github.ErrorResponse
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "" // the job exceeded its max runtime, see worker.handle
+	case errors.As(err, new(*github.RateLimitError)), errors.As(err, new(*github.AbuseRateLimitError)):
+		return retryOnGitHub
+	case errors.As(err, &ghErr):
+		if ghErr.Response != nil && ghErr.Response.StatusCode >= 500 {
+			return retryOnGitHub
+		}
+	case errors.As(err, &pgErr):
+		switch {
+		case strings.HasPrefix(pgErr.Code, "08"), // connection exception
+			pgErr.Code == "40001",                // serialization failure
+			pgErr.Code == "40P01",                // deadlock detected
+			strings.HasPrefix(pgErr.Code, "53"),  // insufficient resources
+			strings.HasPrefix(pgErr.Code, "57P"): // operator intervention, e.g. the server is shutting down
+			return retryOnDatabase
+		}
+	case pgconn.Timeout(err):
+		return retryOnDatabase
+	case errors.As(err, &netErr), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, syscall.ECONNRESET):
+		return retryOnNetwork
+	}
+
+	return ""
+}
+
+// shouldRetry reports whether the error is retried by a sync type retrying the given classes of errors
+func shouldRetry(err error, classes []string) bool {
+	if registry.IsRetryable(err) {
+		return true
+	}
+
+	if class := errorClass(err); class != "" {
+		for _, c := range classes {
+			if c == class {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// fail handles the failure of an attempt of the job. If the error is retryable and the job has attempts left,
+// according to the retry policy of its sync type, the job is queued again, with a new sqlq job running it after
+// a backoff (see mergestat.repo_sync_queue_enqueue_sqlq_job). Otherwise it's marked as FAILED.
+func (w *worker) fail(ctx context.Context, j *db.DequeueSyncJobRow, cause error) error {
+	var policy, err = w.db.GetSyncJobRetryPolicy(ctx, j.ID)
+	if err != nil {
+		return fmt.Errorf("fetch retry policy: %w", err)
+	}
+
+	if j.Attempt < policy.RetryMaxAttempts && shouldRetry(cause, policy.RetryOn) {
+		runAfter, err := w.db.RetrySyncJob(ctx, j.ID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil // the job isn't running anymore (e.g. it was marked as done by the handler before failing)
+			}
+			return fmt.Errorf("retry sync job: %w", err)
+		}
+
+		return w.sendBatchLogMessages(ctx, []*syncLog{{Type: SyncLogTypeWarn, RepoSyncQueueID: j.ID,
+			Message: fmt.Sprintf("attempt %d of %d failed, retrying after %s: %v",
+				j.Attempt, policy.RetryMaxAttempts, runAfter.Time.Format(time.RFC3339), cause),
+		}})
+	}
+
+	if err = w.db.InsertSyncJobLog(ctx, db.InsertSyncJobLogParams{
+		LogType:         string(SyncLogTypeError),
+		Message:         cause.Error(),
+		RepoSyncQueueID: j.ID,
+	}); err != nil {
+		return fmt.Errorf("send log error message: %w", err)
+	}
+
+	if err = w.db.SetSyncJobStatus(ctx, db.SetSyncJobStatusParams{Status: "FAILED", ID: j.ID}); err != nil {
+		return fmt.Errorf("mark sync job as failed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+	"github.com/jackc/pgconn"
+	"github.com/mergestat/mergestat/pkg/registry"
+)
+
+func TestErrorClass(t *testing.T) {
+	type testArgs struct {
+		description string
+		err         error
+		want        string
+	}
+
+	var githubError = func(status int) error {
+		return &github.ErrorResponse{Response: &http.Response{StatusCode: status}}
+	}
+
+	tests := []testArgs{
+		{description: "nil error", err: nil, want: ""},
+		{description: "unknown error", err: errors.New("boom"), want: ""},
+		{description: "max runtime exceeded", err: fmt.Errorf("sync: %w", context.DeadlineExceeded), want: ""},
+		{description: "github rate limit", err: &github.RateLimitError{}, want: retryOnGitHub},
+		{description: "github abuse rate limit", err: fmt.Errorf("list prs: %w", &github.AbuseRateLimitError{}), want: retryOnGitHub},
+		{description: "github server error", err: githubError(http.StatusBadGateway), want: retryOnGitHub},
+		{description: "github client error", err: githubError(http.StatusNotFound), want: ""},
+		{description: "postgres connection exception", err: &pgconn.PgError{Code: "08006"}, want: retryOnDatabase},
+		{description: "postgres serialization failure", err: &pgconn.PgError{Code: "40001"}, want: retryOnDatabase},
+		{description: "postgres deadlock", err: fmt.Errorf("insert: %w", &pgconn.PgError{Code: "40P01"}), want: retryOnDatabase},
+		{description: "postgres too many connections", err: &pgconn.PgError{Code: "53300"}, want: retryOnDatabase},
+		{description: "postgres shutting down", err: &pgconn.PgError{Code: "57P01"}, want: retryOnDatabase},
+		{description: "postgres unique violation", err: &pgconn.PgError{Code: "23505"}, want: ""},
+		{description: "network error", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: retryOnNetwork},
+		{description: "unexpected eof", err: fmt.Errorf("clone: %w", io.ErrUnexpectedEOF), want: retryOnNetwork},
+		{description: "connection reset", err: syscall.ECONNRESET, want: retryOnNetwork},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if got := errorClass(test.err); got != test.want {
+				t.Fatalf("errorClass(%v) = %q, want %q", test.err, got, test.want)
+			}
+		})
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	type testArgs struct {
+		description string
+		err         error
+		classes     []string
+		want        bool
+	}
+
+	tests := []testArgs{
+		{description: "marked as retryable", err: registry.Retryable(errors.New("boom")), classes: nil, want: true},
+		{description: "wraps an error marked as retryable", err: fmt.Errorf("sync: %w", registry.Retryable(errors.New("boom"))), classes: nil, want: true},
+		{description: "class retried by the sync type", err: io.ErrUnexpectedEOF, classes: []string{retryOnDatabase, retryOnNetwork}, want: true},
+		{description: "class not retried by the sync type", err: io.ErrUnexpectedEOF, classes: []string{retryOnGitHub}, want: false},
+		{description: "no classes retried", err: &github.RateLimitError{}, classes: nil, want: false},
+		{description: "unknown error", err: errors.New("boom"), classes: []string{retryOnNetwork, retryOnDatabase, retryOnGitHub}, want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if got := shouldRetry(test.err, test.classes); got != test.want {
+				t.Fatalf("shouldRetry(%v, %q) = %v, want %v", test.err, test.classes, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package syncer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgtype"
+	"github.com/mergestat/mergestat/internal/db"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// gitSettings are the settings shared by the git syncs that process the history of a repo
+type gitSettings struct {
+	Refs []string `json:"refs"` // names or globs of the branches and tags to sync
+}
+
+// gitBlameSettings are the settings of a GIT_BLAME sync
+type gitBlameSettings struct {
+	gitSettings
+
+	Concurrency int      `json:"concurrency"` // number of files blamed in parallel
+	Include     []string `json:"include"`     // globs of the files to blame, all files if empty
+	Exclude     []string `json:"exclude"`     // globs of the files not to blame
+	MaxFileSize int64    `json:"maxFileSize"` // size in bytes above which files are not blamed
+}
+
+// includes reports whether the file at the given path should be blamed
+func (s *gitBlameSettings) includes(p string) bool {
+	if len(s.Include) > 0 && !matchAny(s.Include, p) {
+		return false
+	}
+	return !matchAny(s.Exclude, p)
+}
+
+// hash returns a hash of the settings that determine which files are blamed, recorded with the checkpoints
+// of the sync so that a change of them triggers blaming all files again
+func (s *gitBlameSettings) hash() string {
+	var b, _ = json.Marshal(struct {
+		Include     []string `json:"include"`
+		Exclude     []string `json:"exclude"`
+		MaxFileSize int64    `json:"maxFileSize"`
+	}{s.Include, s.Exclude, s.MaxFileSize})
+	return fmt.Sprintf("%x", sha1.Sum(b))
+}
+
+// gitFilesSettings are the settings of a GIT_FILES sync
+type gitFilesSettings struct {
+	gitSettings
+
+	MaxFileSize int64 `json:"maxFileSize"` // size in bytes above which the contents of files are not stored
+}
+
+// githubPRSettings are the settings of a GITHUB_PRS_AND_COMMITS sync
+type githubPRSettings struct {
+	LookbackDays int `json:"lookbackDays"` // only sync the pull requests updated in this many days, if set
+	PerPage      int `json:"perPage"`      // page size of the requests to the GitHub API
+}
+
+// trivySettings are the settings of a TRIVY_REPO_SCAN sync
+type trivySettings struct {
+	Severity []string `json:"severity"` // severities of the vulnerabilities to report, all if empty
+}
+
+// parseSettings decodes the settings of the sync into v, which is left untouched if the sync has none
+func parseSettings(j *db.DequeueSyncJobRow, v interface{}) error {
+	if j.Settings.Status != pgtype.Present {
+		return nil
+	}
+
+	if err := json.Unmarshal(j.Settings.Bytes, v); err != nil {
+		return fmt.Errorf("parse sync settings: %w", err)
+	}
+
+	return nil
+}
+
+// compiledSchemas caches the compiled settings schemas of the sync types (as *jsonschema.Schema), keyed by the sync type
+// and the hash of the schema, so that a schema is only compiled again once it's changed
+var compiledSchemas sync.Map
+
+// validateSettings validates the settings of the sync against the JSON schema of its type, if it has one
+func (w *worker) validateSettings(ctx context.Context, j *db.DequeueSyncJobRow) error {
+	schema, err := w.db.GetRepoSyncTypeSettingsSchema(ctx, j.SyncType)
+	if err != nil {
+		return fmt.Errorf("fetch settings schema: %w", err)
+	}
+
+	if schema.Status != pgtype.Present {
+		return nil
+	}
+
+	var compiled *jsonschema.Schema
+	var key = fmt.Sprintf("%s@%x", j.SyncType, sha1.Sum(schema.Bytes))
+	if cached, ok := compiledSchemas.Load(key); ok {
+		compiled = cached.(*jsonschema.Schema)
+	} else {
+		var compiler = jsonschema.NewCompiler()
+		var url = fmt.Sprintf("mergestat://repo_sync_types/%s/settings_schema.json", j.SyncType)
+		if err = compiler.AddResource(url, bytes.NewReader(schema.Bytes)); err != nil {
+			return fmt.Errorf("load settings schema of %s: %w", j.SyncType, err)
+		}
+
+		if compiled, err = compiler.Compile(url); err != nil {
+			return fmt.Errorf("compile settings schema of %s: %w", j.SyncType, err)
+		}
+		compiledSchemas.Store(key, compiled)
+	}
+
+	// numbers are decoded as json.Number, as expected by the validator
+	var settings interface{} = map[string]interface{}{}
+	if j.Settings.Status == pgtype.Present {
+		var decoder = json.NewDecoder(bytes.NewReader(j.Settings.Bytes))
+		decoder.UseNumber()
+		if err = decoder.Decode(&settings); err != nil {
+			return fmt.Errorf("parse sync settings: %w", err)
+		}
+	}
+
+	if err = compiled.Validate(settings); err != nil {
+		return fmt.Errorf("invalid settings for sync type %s: %w", j.SyncType, err)
+	}
+
+	return nil
+}
+
+// matchAny reports whether the path matches any of the globs. Globs without a slash are matched against
+// the name of the file (e.g. "*.min.js") and globs ending with a slash match everything in a directory (e.g. "vendor/"),
+// at any depth unless the glob has another slash (e.g. "third_party/*/").
+func matchAny(globs []string, p string) bool {
+	for _, glob := range globs {
+		switch {
+		case strings.HasSuffix(glob, "/"):
+			var dirGlob = strings.TrimSuffix(glob, "/")
+			for dir := path.Dir(p); dir != "." && dir != "/"; dir = path.Dir(dir) {
+				var name = dir
+				if !strings.Contains(dirGlob, "/") {
+					name = path.Base(dir)
+				}
+
+				if ok, _ := path.Match(dirGlob, name); ok {
+					return true
+				}
+			}
+		case !strings.Contains(glob, "/"):
+			if ok, _ := path.Match(glob, path.Base(p)); ok {
+				return true
+			}
+		default:
+			if ok, _ := path.Match(glob, p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
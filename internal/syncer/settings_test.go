@@ -0,0 +1,36 @@
+package syncer
+
+import "testing"
+
+func TestMatchAny(t *testing.T) {
+	type testArgs struct {
+		description string
+		globs       []string
+		path        string
+		want        bool
+	}
+
+	tests := []testArgs{
+		{description: "no globs", globs: nil, path: "main.go", want: false},
+		{description: "name glob at the root", globs: []string{"*.min.js"}, path: "app.min.js", want: true},
+		{description: "name glob in a directory", globs: []string{"*.min.js"}, path: "static/js/app.min.js", want: true},
+		{description: "name glob not matching", globs: []string{"*.min.js"}, path: "static/js/app.js", want: false},
+		{description: "directory glob", globs: []string{"vendor/"}, path: "vendor/github.com/pkg/errors/errors.go", want: true},
+		{description: "directory glob matches nested directories", globs: []string{"node_modules/"}, path: "web/node_modules/react/index.js", want: true},
+		{description: "directory glob doesn't match files of the same name", globs: []string{"vendor/"}, path: "vendor", want: false},
+		{description: "directory glob with wildcard", globs: []string{"third_party/*/"}, path: "third_party/zlib/src/zlib.c", want: true},
+		{description: "path glob", globs: []string{"docs/*.md"}, path: "docs/README.md", want: true},
+		{description: "path glob is anchored at the root", globs: []string{"docs/*.md"}, path: "web/docs/README.md", want: false},
+		{description: "path glob doesn't match nested files", globs: []string{"docs/*.md"}, path: "docs/api/README.md", want: false},
+		{description: "any of the globs", globs: []string{"*.lock", "vendor/"}, path: "yarn.lock", want: true},
+		{description: "invalid glob", globs: []string{"[", "*.go"}, path: "main.go", want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if got := matchAny(test.globs, test.path); got != test.want {
+				t.Fatalf("matchAny(%q, %q) = %v, want %v", test.globs, test.path, got, test.want)
+			}
+		})
+	}
+}
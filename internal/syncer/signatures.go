@@ -0,0 +1,110 @@
+package syncer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	libgit2 "github.com/libgit2/git2go/v33"
+	"golang.org/x/crypto/ssh"
+)
+
+// types of the signatures of commits, as git names the formats of gpg.format
+const (
+	signatureTypeGPG  = "gpg"
+	signatureTypeSSH  = "ssh"
+	signatureTypeX509 = "x509"
+)
+
+// commitSignature returns the type of the signature of the commit, and the ID of the key that signed it,
+// as shown by git log --format=%GK. The type is empty if the commit isn't signed, and the key ID is empty
+// if it can't be determined from the signature (e.g. for x509 signatures).
+func commitSignature(c *libgit2.Commit) (sigType, keyID string, err error) {
+	var signature string
+	if signature, _, err = c.ExtractSignature(); err != nil {
+		if libgit2.IsErrorCode(err, libgit2.ErrorCodeNotFound) {
+			return "", "", nil
+		}
+		return "", "", err
+	}
+
+	switch {
+	case strings.HasPrefix(signature, "-----BEGIN PGP SIGNATURE-----"):
+		keyID, err = gpgKeyID(signature)
+		return signatureTypeGPG, keyID, err
+	case strings.HasPrefix(signature, "-----BEGIN SSH SIGNATURE-----"):
+		keyID, err = sshKeyID(signature)
+		return signatureTypeSSH, keyID, err
+	case strings.HasPrefix(signature, "-----BEGIN SIGNED MESSAGE-----"):
+		return signatureTypeX509, "", nil
+	default:
+		return "", "", errors.New("unknown signature format")
+	}
+}
+
+// gpgKeyID returns the long ID of the key that made the OpenPGP signature, in hex
+func gpgKeyID(signature string) (string, error) {
+	block, err := armor.Decode(strings.NewReader(signature))
+	if err != nil {
+		return "", fmt.Errorf("decode armor: %w", err)
+	}
+
+	p, err := packet.Read(block.Body)
+	if err != nil {
+		return "", fmt.Errorf("read packet: %w", err)
+	}
+
+	sig, ok := p.(*packet.Signature)
+	if !ok {
+		return "", fmt.Errorf("unexpected packet %T", p)
+	}
+
+	switch {
+	case sig.IssuerKeyId != nil:
+		return fmt.Sprintf("%016X", *sig.IssuerKeyId), nil
+	case len(sig.IssuerFingerprint) > 0:
+		return fmt.Sprintf("%X", sig.IssuerFingerprint), nil
+	default:
+		return "", nil
+	}
+}
+
+// sshKeyID returns the SHA256 fingerprint of the public key that made the SSH signature,
+// which is stored in the signature itself (see https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.sshsig)
+func sshKeyID(signature string) (string, error) {
+	var body strings.Builder
+	for _, line := range strings.Split(signature, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "-----") {
+			body.WriteString(line)
+		}
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(body.String())
+	if err != nil {
+		return "", fmt.Errorf("decode signature: %w", err)
+	}
+
+	// the magic preamble and the version of the format precede the public key
+	const preamble = "SSHSIG"
+	if !bytes.HasPrefix(blob, []byte(preamble)) || len(blob) < len(preamble)+8 {
+		return "", errors.New("invalid ssh signature")
+	}
+	blob = blob[len(preamble)+4:]
+
+	var n = binary.BigEndian.Uint32(blob)
+	if blob = blob[4:]; uint64(n) > uint64(len(blob)) {
+		return "", errors.New("invalid ssh signature")
+	}
+
+	key, err := ssh.ParsePublicKey(blob[:n])
+	if err != nil {
+		return "", fmt.Errorf("parse public key: %w", err)
+	}
+
+	return ssh.FingerprintSHA256(key), nil
+}
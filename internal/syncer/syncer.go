@@ -3,25 +3,37 @@ package syncer
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-git/go-billy/v5/osfs"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/storer"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 
-	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/mattn/go-sqlite3"
 	_ "github.com/mergestat/mergestat-lite/pkg/sqlite"
 	"github.com/mergestat/mergestat/internal/db"
+	"github.com/mergestat/mergestat/internal/helper"
+	"github.com/mergestat/mergestat/internal/metrics"
+	"github.com/mergestat/mergestat/pkg/registry"
 	"github.com/rs/zerolog"
 )
 
@@ -51,174 +63,148 @@ const (
 
 var errGitHubTokenRequired = errors.New("in order to run this syncer, a GitHub authentication token must be present")
 
+// errSyncCanceled is returned by handle when the job was canceled from the database while running
+var errSyncCanceled = errors.New("sync job was canceled")
+
+// errSyncTimedOut is returned by handle when the job exceeded the max runtime of its sync type
+var errSyncTimedOut = errors.New("sync job exceeded the maximum runtime")
+
 type worker struct {
-	logger       *zerolog.Logger
-	pool         *pgxpool.Pool
-	mergestat    *sqlx.DB
-	db           *db.Queries
-	concurrency  int
-	pollInterval time.Duration
+	id          uuid.UUID // ID of the worker in mergestat.workers
+	logger      *zerolog.Logger
+	pool        *pgxpool.Pool
+	mergestat   *sqlx.DB
+	db          *db.Queries
+	upstream    *sql.DB // connection to the database used by sqlq
+	concurrency int
+	cache       *cloneCache
+
+	slots    chan struct{}  // a token per job being executed, shared by the sqlq worker and wakeup, up to the concurrency
+	inflight sync.WaitGroup // jobs dequeued by the worker itself, see wakeup
+
+	capabilities []string // capabilities available to the worker
+	syncTypes    []string // sync types the worker runs, see probe
 }
 
-func New(pool *pgxpool.Pool, mergestat *sqlx.DB, logger *zerolog.Logger, concurrency int, pollInterval time.Duration) *worker {
+func New(pool *pgxpool.Pool, upstream *sql.DB, mergestat *sqlx.DB, logger *zerolog.Logger, concurrency int) *worker {
+	// GIT_CLONE_CACHE_MAX_SIZE_MB caps the disk space used by repo clones, 0 (the default) means no limit
+	var maxCacheSize int64
+	if size := os.Getenv("GIT_CLONE_CACHE_MAX_SIZE_MB"); size != "" {
+		if mb, err := strconv.ParseInt(size, 10, 64); err != nil {
+			logger.Err(err).Msgf("could not parse GIT_CLONE_CACHE_MAX_SIZE_MB env into an int: %s", size)
+		} else {
+			maxCacheSize = mb * 1024 * 1024
+		}
+	}
+
+	// the sqlq worker runs as many jobs as there are CPUs without a concurrency, so does the worker
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	var capabilities, syncTypes = probe(logger)
+
 	return &worker{
+		id:           uuid.New(),
 		logger:       logger,
 		pool:         pool,
 		mergestat:    mergestat,
 		db:           db.New(pool),
+		upstream:     upstream,
 		concurrency:  concurrency,
-		pollInterval: pollInterval,
+		slots:        make(chan struct{}, concurrency),
+		cache:        newCloneCache(logger, os.Getenv("GIT_CLONE_PATH"), maxCacheSize),
+		capabilities: capabilities,
+		syncTypes:    syncTypes,
 	}
 }
 
-// dequeue blocks until a job is available or the context is canceled.
-// It checks for new jobs on the syncer pollInterval
-func (w *worker) dequeue(ctx context.Context) (*db.DequeueSyncJobRow, error) {
-	for {
-		select {
-		case _, ok := <-ctx.Done():
-			if !ok {
-				return nil, ctx.Err()
-			}
-		case <-time.After(w.pollInterval):
-			var job db.DequeueSyncJobRow
-			var err error
-			if job, err = w.db.DequeueSyncJob(ctx); err != nil {
-				if errors.Is(err, pgx.ErrNoRows) {
-					continue
-				}
-				return nil, err
-			}
-
-			return &job, nil
-		}
+// observe records the outcome and duration of the job in the metrics
+func observe(j *db.DequeueSyncJobRow, started time.Time, err error) {
+	var outcome string
+	switch {
+	case err == nil:
+		outcome = metrics.OutcomeSucceeded
+		metrics.JobsSucceeded.WithLabelValues(j.SyncType).Inc()
+	case errors.Is(err, errSyncCanceled), errors.Is(err, context.Canceled):
+		outcome = metrics.OutcomeCanceled
+	default:
+		outcome = metrics.OutcomeFailed
+		metrics.JobsFailed.WithLabelValues(j.SyncType).Inc()
 	}
-}
 
-// exec loops until the context is canceled, executing a sync.
-func (w *worker) exec(ctx context.Context, id string) {
-	w.logger.Info().Msgf("starting exec loop: %s", id)
-	for {
-		select {
-		case _, ok := <-ctx.Done():
-			if !ok {
-				w.logger.Info().Msgf("exiting exec loop: %s", id)
-				return
-			}
-		default:
-			j, err := w.dequeue(ctx)
-			if err != nil {
-				// if error is a context cancellation, go to next tick of loop where
-				// done case will be selected
-				if errors.Is(err, context.Canceled) {
-					continue
-				}
-
-				w.logger.Err(err).Msgf("error dequeuing job: %v", err)
-				continue
-			}
+	metrics.JobDuration.WithLabelValues(j.SyncType, outcome).Observe(time.Since(started).Seconds())
+}
 
-			w.loggerForJob(j).Info().Msg("dequeued job")
-
-			if err := w.handle(ctx, j); err != nil {
-				if !errors.Is(err, context.Canceled) {
-					w.logger.Warn().AnErr("error", err).Msgf("error handling job: %v", j)
-
-					if err := w.db.InsertSyncJobLog(context.TODO(), db.InsertSyncJobLogParams{
-						LogType:         string(SyncLogTypeError),
-						Message:         err.Error(),
-						RepoSyncQueueID: j.ID,
-					}); err != nil {
-						w.logger.Err(err).Msgf("error sending log error message: %v", err)
-					}
-
-					if err := w.db.SetSyncJobStatus(context.TODO(), db.SetSyncJobStatusParams{
-						Status: "DONE",
-						ID:     j.ID,
-					}); err != nil {
-						w.logger.Err(err).Msgf("error marking sync job as done: %v", err)
-					}
-					continue
-				} else {
-					// if the error was a context cancellation, reset the status to QUEUED
-					if err := w.db.SetSyncJobStatus(context.TODO(), db.SetSyncJobStatusParams{
-						Status: "QUEUED",
-						ID:     j.ID,
-					}); err != nil {
-						w.logger.Err(err).Msgf("error marking sync job as queued: %v", err)
-					}
-				}
-			}
-		}
-	}
+// rowsInserted records the number of rows inserted by the job into the table in the metrics
+func rowsInserted(j *db.DequeueSyncJobRow, table string, n int) {
+	metrics.RowsInserted.WithLabelValues(j.SyncType, table).Add(float64(n))
 }
 
-// handle maps jobs to the right handler (see handlers.go)
+// handle executes the job using the handler registered for its sync type (see registry.go)
 func (w *worker) handle(ctx context.Context, j *db.DequeueSyncJobRow) error {
 	w.loggerForJob(j).Info().Msg("handling job")
 
-	done := w.startKeepAlives(j, 30*time.Second)
+	// the handler's context is canceled if the cancellation of the job is requested
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var canceled atomic.Bool
+	done := w.startKeepAlives(j, 30*time.Second, func() { canceled.Store(true); cancel() })
 	defer done()
 
-	switch j.SyncType {
-	case syncTypeGitCommits:
-		return w.handleGitCommits(ctx, j)
-	case syncTypeGitFiles:
-		return w.handleGitFiles(ctx, j)
-	case syncTypeGitCommitStats:
-		return w.handleGitCommitStats(ctx, j)
-	case syncTypeGitRefs:
-		return w.handleGitRefs(ctx, j)
-	case syncTypeGitBlame:
-		return w.handleGitBlame(ctx, j)
-	case syncTypeGitRemotes:
-		return w.handleGitRemotes(ctx, j)
-	case syncTypeGitHubRepoMetadata:
-		return w.handleGitHubRepoMetadata(ctx, j)
-	case syncTypeGitHubRepoPRs:
-		return w.handleGitHubRepoPRs(ctx, j)
-	case syncTypeGitHubRepoIssues:
-		return w.handleGitHubRepoIssues(ctx, j)
-	case syncTypeGitHubRepoStars:
-		return w.handleGitHubRepoStars(ctx, j)
-	case syncTypeGitHubPRReviews:
-		return w.handleGitHubPRReviews(ctx, j)
-	case syncTypeGitHubPRCommits:
-		return w.handleGitHubPRCommits(ctx, j)
-	case syncTypeGitHubPRsAndCommits:
-		return w.handleGitHubRepoPRsAndCommits(ctx, j)
-	case syncTypeTrivyRepoScan:
-		return w.handleTrivyRepoScan(ctx, j)
-	case syncTypeSyftRepoScan:
-		return w.handleSyftRepoScan(ctx, j)
-	case syncTypeGitHubActions:
-		return w.handleGithubActions(ctx, j)
-	case syncTypeGitleaksRepoScan:
-		return w.handleGitleaksRepoScan(ctx, j)
-	case syncTypeYelpDetectSecretsRepoScan:
-		return w.handleYelpDetectSecretsRepoScan(ctx, j)
-	case syncTypeGosecRepoScan:
-		return w.handleGosecRepoScan(ctx, j)
-	case syncTypeOSSFScorecardRepoScan:
-		return w.handleOSSFScorecardScan(ctx, j)
-	case syncTypeGrypeScan:
-		return w.handleGrypeRepoScan(ctx, j)
-	default:
-		return fmt.Errorf("unknown sync type: %s for job ID: %d", j.SyncType, j.ID)
+	// the handler's context also expires once the job exceeds the max runtime of its sync type, if any
+	var maxRuntime, limited = helper.IntervalToDuration(j.MaxRuntime)
+	if limited {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, maxRuntime)
+		defer cancelTimeout()
+	}
+
+	var reg, ok = registry.Lookup(j.SyncType)
+	if !ok {
+		return fmt.Errorf("unknown sync type: %s for job ID: %d, no handler is registered for it in this worker (registered types: %s)",
+			j.SyncType, j.ID, strings.Join(registry.Names(), ", "))
+	}
+
+	if err := w.validateSettings(ctx, j); err != nil {
+		return err
+	}
+
+	if reg.Requires(registry.CapabilityGitHubToken) {
+		if _, token, err := w.fetchCredentials(ctx, j); err != nil {
+			return err
+		} else if token == "" {
+			return errGitHubTokenRequired
+		}
+	}
+
+	if err := reg.Handler.Handle(ctx, &jobRuntime{worker: w, job: j}, registryJob(j)); err != nil {
+		if canceled.Load() {
+			return errSyncCanceled
+		}
+		if limited && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%w of %s", errSyncTimedOut, maxRuntime)
+		}
+		return err
 	}
+
+	return nil
 }
 
-// Start starts running the workers until the ctx is canceled.
+// Start registers the worker in mergestat.workers and keeps it alive until the ctx is canceled, listening for
+// queued jobs in the meantime. Syncs are executed by the sqlq worker, see RegisterHandlers, and by listen.
 func (w *worker) Start(ctx context.Context) {
-	g := &sync.WaitGroup{}
-	g.Add(w.concurrency)
-	for i := 0; i < w.concurrency; i++ {
-		go func(i int) {
-			w.exec(ctx, fmt.Sprintf("%d", i))
-			g.Done()
-		}(i)
-	}
-	g.Wait()
+	if err := w.register(ctx); err != nil {
+		return
+	}
+	defer w.unregister()
+
+	go w.listen(ctx)
+	w.heartbeat(ctx)
+
+	// the jobs dequeued by listen are canceled along with ctx, wait for them to be queued again
+	w.inflight.Wait()
 }
 
 func (w *worker) fetchCredentials(ctx context.Context, job *db.DequeueSyncJobRow) (_, _ string, err error) {
@@ -235,24 +221,93 @@ func (w *worker) fetchCredentials(ctx context.Context, job *db.DequeueSyncJobRow
 	return username, token, nil
 }
 
-// clone clones the repository tied to this job into the given path.
+// checkout makes sure an up-to-date clone of the repository tied to this job is available
+// in the clone cache, and returns the path to it. The clone is shared with other syncs of the same repo,
+// which may update it at any time, so callers must only read its objects and refs (e.g. at a commit resolved
+// up front) and never its working tree; use checkoutWorktree for that. The returned release function must be
+// called once the caller is done reading from the clone.
+func (w *worker) checkout(ctx context.Context, job *db.DequeueSyncJobRow) (_ string, _ func(), err error) {
+	var entry = w.cache.acquire(job.RepoID)
+	if err = w.update(ctx, entry, job, nil); err != nil {
+		w.cache.release(entry)
+		return "", nil, err
+	}
+
+	return entry.path, func() { w.cache.release(entry) }, nil
+}
+
+// checkoutWorktree is like checkout, but returns the path to a private clone of the repository with its own
+// working tree, which is safe to run tools in (like scanners). The private clone borrows the objects of the
+// shared clone (see git clone --shared) and is removed by the returned release function.
+func (w *worker) checkoutWorktree(ctx context.Context, job *db.DequeueSyncJobRow) (_ string, _ func(), err error) {
+	var entry = w.cache.acquire(job.RepoID)
+
+	// the working tree is locked until it's removed, so that other workers sharing the cache leave it alone
+	var worktree string
+	var lock *os.File
+	if err = w.update(ctx, entry, job, func() (err error) {
+		if worktree, err = os.MkdirTemp(w.cache.basePath, worktreeDirPrefix+w.id.String()+"-"); err != nil {
+			return err
+		}
+
+		if lock, err = tryLock(worktree); err != nil || lock == nil {
+			_ = os.RemoveAll(worktree)
+			if err == nil {
+				err = errors.New("locked by another worker")
+			}
+			return errors.Wrapf(err, "failed to lock working tree")
+		}
+
+		var cmd = exec.CommandContext(ctx, "git", "clone", "--quiet", "--shared", entry.path, worktree)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			_ = os.RemoveAll(worktree)
+			_ = lock.Close()
+			return errors.Wrapf(err, "failed to create working tree: %s", out)
+		}
+		return nil
+	}); err != nil {
+		w.cache.release(entry)
+		return "", nil, err
+	}
+
+	return worktree, func() {
+		if err := os.RemoveAll(worktree); err != nil {
+			w.logger.Err(err).Msgf("error cleaning up working tree at: %s, %v", worktree, err)
+		}
+		_ = lock.Close()
+		w.cache.release(entry)
+	}, nil
+}
+
+// update clones or fetches the repository into the given cache entry, and calls then (if not nil)
+// while still holding the lock of the entry. It gives up waiting for the lock once the ctx is done.
+func (w *worker) update(ctx context.Context, entry *cloneCacheEntry, job *db.DequeueSyncJobRow, then func() error) (err error) {
+	if err = entry.lock(ctx); err != nil {
+		return err
+	}
+	defer entry.unlock()
+
+	if err = w.clone(ctx, entry.path, job); err != nil {
+		return err
+	}
+	w.cache.updated(entry)
+
+	if then != nil {
+		return then()
+	}
+	return nil
+}
+
+// clone clones the repository tied to this job into the given path. If the repository
+// was already cloned into path, it fetches from the remote and updates the working tree instead.
 func (w *worker) clone(ctx context.Context, path string, job *db.DequeueSyncJobRow) (err error) {
 	var logger = w.logger.With().Str("repo", job.RepoID.String()).Logger()
-	logger.Info().Msgf("starting git repository clone")
 
 	var repo db.Repo
 	if repo, err = w.db.GetRepoById(ctx, job.RepoID); err != nil {
 		return err
 	}
 
-	if err = w.sendBatchLogMessages(ctx, []*syncLog{{
-		Type:            SyncLogTypeInfo,
-		RepoSyncQueueID: job.ID,
-		Message:         "starting git clone: " + repo.Repo,
-	}}); err != nil {
-		return err
-	}
-
 	// TODO(@riyaz): we can improve this by first detecting the kind of url
 	// 		and then fetching the appropriate type of credential for it.
 	// 		This still involves couple of challenges (differentiating between different provider tokens etc.)
@@ -293,20 +348,202 @@ func (w *worker) clone(ctx context.Context, path string, job *db.DequeueSyncJobR
 	var dotgit, _ = fs.Chroot(".git")
 	var target = filesystem.NewStorage(dotgit, cache.NewObjectLRUDefault())
 
-	var opts = &git.CloneOptions{URL: endpoint.String(), Auth: auth}
-	if _, err = git.CloneContext(ctx, target, fs, opts); err != nil {
-		return errors.Wrapf(err, "failed to clone repository")
+	var repository *git.Repository
+	if repository, err = git.Open(target, fs); err != nil && !errors.Is(err, git.ErrRepositoryNotExists) {
+		// the existing clone is unusable (probably from an interrupted clone), start over with a fresh one
+		logger.Warn().AnErr("error", err).Msgf("failed to open cached git repository, removing it: %s", path)
+		if err = os.RemoveAll(path); err != nil {
+			return errors.Wrapf(err, "failed to remove cached repository")
+		}
+		return w.clone(ctx, path, job)
+	} else if errors.Is(err, git.ErrRepositoryNotExists) {
+		logger.Info().Msgf("starting git repository clone")
+
+		if err = w.sendBatchLogMessages(ctx, []*syncLog{{
+			Type:            SyncLogTypeInfo,
+			RepoSyncQueueID: job.ID,
+			Message:         "starting git clone: " + repo.Repo,
+		}}); err != nil {
+			return err
+		}
+
+		var opts = &git.CloneOptions{URL: endpoint.String(), Auth: auth}
+		if _, err = git.CloneContext(ctx, target, fs, opts); err != nil {
+			// do not leave a partial clone behind in the cache
+			if rmErr := os.RemoveAll(path); rmErr != nil {
+				logger.Err(rmErr).Msgf("error cleaning up repo at: %s, %v", path, rmErr)
+			}
+			return errors.Wrapf(err, "failed to clone repository")
+		}
+
+		logger.Info().Msgf("finished git repository clone: %s", repo.Repo)
+
+		if err = w.sendBatchLogMessages(ctx, []*syncLog{{
+			Type:            SyncLogTypeInfo,
+			RepoSyncQueueID: job.ID,
+			Message:         "finished git clone successfully: " + repo.Repo,
+		}}); err != nil {
+			return err
+		}
+	} else {
+		logger.Info().Msgf("starting git repository fetch")
+
+		if err = w.sendBatchLogMessages(ctx, []*syncLog{{
+			Type:            SyncLogTypeInfo,
+			RepoSyncQueueID: job.ID,
+			Message:         "starting git fetch: " + repo.Repo,
+		}}); err != nil {
+			return err
+		}
+
+		var opts = &git.FetchOptions{RemoteName: git.DefaultRemoteName, Auth: auth, Tags: git.AllTags, Force: true}
+		if err = repository.FetchContext(ctx, opts); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return errors.Wrapf(err, "failed to fetch newer changes from origin")
+		}
+
+		var upstream []*plumbing.Reference
+		if upstream, err = listRemoteRefs(ctx, repository, auth); err != nil {
+			return errors.Wrapf(err, "failed to list remote references")
+		}
+
+		if err = pruneRemoteRefs(repository, upstream); err != nil {
+			return errors.Wrapf(err, "failed to prune deleted branches")
+		}
+
+		if err = resetToRemote(repository, upstream); errors.Is(err, plumbing.ErrReferenceNotFound) || errors.Is(err, plumbing.ErrObjectNotFound) {
+			// the remote HEAD points at something the fetch didn't bring in (e.g. a detached commit), start over with a fresh clone
+			logger.Warn().AnErr("error", err).Msgf("failed to check out remote HEAD, removing cached repository: %s", path)
+			if err = os.RemoveAll(path); err != nil {
+				return errors.Wrapf(err, "failed to remove cached repository")
+			}
+			return w.clone(ctx, path, job)
+		} else if err != nil {
+			return errors.Wrapf(err, "failed to update working tree")
+		}
+
+		logger.Info().Msgf("finished git repository fetch: %s", repo.Repo)
+
+		if err = w.sendBatchLogMessages(ctx, []*syncLog{{
+			Type:            SyncLogTypeInfo,
+			RepoSyncQueueID: job.ID,
+			Message:         "finished git fetch successfully: " + repo.Repo,
+		}}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resetToRemote checks out the commit the HEAD of the remote points at, as listed in upstream, and resets the working
+// tree to it, discarding any local changes and untracked files. If the remote HEAD is a branch (which may have been
+// renamed since the repository was cloned), it's checked out at its remote-tracking branch; else the remote HEAD is
+// detached and so is the local one.
+func resetToRemote(repository *git.Repository, upstream []*plumbing.Reference) (err error) {
+	var remoteHead *plumbing.Reference
+	for _, ref := range upstream {
+		if ref.Name() == plumbing.HEAD {
+			remoteHead = ref
+			break
+		}
+	}
+
+	if remoteHead == nil {
+		return plumbing.ErrReferenceNotFound
+	}
+
+	var head = plumbing.NewHashReference(plumbing.HEAD, remoteHead.Hash())
+	if remoteHead.Type() == plumbing.SymbolicReference {
+		var branch = remoteHead.Target()
+
+		var tracking *plumbing.Reference
+		if tracking, err = repository.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, branch.Short()), true); err != nil {
+			return err
+		}
+
+		if err = repository.Storer.SetReference(plumbing.NewHashReference(branch, tracking.Hash())); err != nil {
+			return err
+		}
+		head = plumbing.NewSymbolicReference(plumbing.HEAD, branch)
+	}
+
+	// remove the branch checked out until now if it's not the one of the remote HEAD anymore
+	var previous *plumbing.Reference
+	if previous, err = repository.Storer.Reference(plumbing.HEAD); err != nil {
+		return err
+	}
+
+	if err = repository.Storer.SetReference(head); err != nil {
+		return err
+	}
+
+	if previous.Type() == plumbing.SymbolicReference && previous.Target() != head.Target() {
+		if err = repository.Storer.RemoveReference(previous.Target()); err != nil {
+			return err
+		}
+	}
+
+	var commit *plumbing.Reference
+	if commit, err = repository.Reference(plumbing.HEAD, true); err != nil {
+		return err
+	}
+
+	var worktree *git.Worktree
+	if worktree, err = repository.Worktree(); err != nil {
+		return err
+	}
+
+	if err = worktree.Reset(&git.ResetOptions{Commit: commit.Hash(), Mode: git.HardReset}); err != nil {
+		return err
 	}
 
-	logger.Info().Msgf("finished git repository clone: %s", repo.Repo)
+	return worktree.Clean(&git.CleanOptions{Dir: true})
+}
+
+// listRemoteRefs lists the references of the default remote of the repository, including its HEAD.
+func listRemoteRefs(ctx context.Context, repository *git.Repository, auth transport.AuthMethod) (_ []*plumbing.Reference, err error) {
+	var remote *git.Remote
+	if remote, err = repository.Remote(git.DefaultRemoteName); err != nil {
+		return nil, err
+	}
 
-	if err = w.sendBatchLogMessages(ctx, []*syncLog{{
-		Type:            SyncLogTypeInfo,
-		RepoSyncQueueID: job.ID,
-		Message:         "finished git clone successfully: " + repo.Repo,
-	}}); err != nil {
+	return remote.ListContext(ctx, &git.ListOptions{Auth: auth})
+}
+
+// pruneRemoteRefs removes the remote-tracking branches of branches that aren't in upstream anymore,
+// as they were deleted on the remote, so they aren't picked up by syncs anymore.
+func pruneRemoteRefs(repository *git.Repository, upstream []*plumbing.Reference) (err error) {
+	var branches = make(map[string]struct{}, len(upstream))
+	for _, ref := range upstream {
+		if ref.Name().IsBranch() {
+			branches[ref.Name().Short()] = struct{}{}
+		}
+	}
+
+	var iter storer.ReferenceIter
+	if iter, err = repository.References(); err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	var stale []plumbing.ReferenceName
+	var prefix = "refs/remotes/" + git.DefaultRemoteName + "/"
+	if err = iter.ForEach(func(ref *plumbing.Reference) error {
+		if name := ref.Name().String(); strings.HasPrefix(name, prefix) && ref.Type() == plumbing.HashReference {
+			if _, ok := branches[strings.TrimPrefix(name, prefix)]; !ok {
+				stale = append(stale, ref.Name())
+			}
+		}
+		return nil
+	}); err != nil {
 		return err
 	}
 
+	for _, name := range stale {
+		if err = repository.Storer.RemoveReference(name); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
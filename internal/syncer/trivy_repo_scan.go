@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os/exec"
+	"strings"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/mergestat/mergestat/internal/db"
@@ -27,7 +28,17 @@ func (w *worker) handleTrivyRepoScan(ctx context.Context, j *db.DequeueSyncJobRo
 		return fmt.Errorf("send batch log messages: %w", err)
 	}
 
-	cmd := exec.CommandContext(ctx, "trivy", "repository", j.Repo, "-q", "-f", "json", "--timeout", "30m")
+	var settings trivySettings
+	if err = parseSettings(j, &settings); err != nil {
+		return err
+	}
+
+	var args = []string{"repository", j.Repo, "-q", "-f", "json", "--timeout", "30m"}
+	if len(settings.Severity) > 0 {
+		args = append(args, "--severity", strings.Join(settings.Severity, ","))
+	}
+
+	cmd := exec.CommandContext(ctx, "trivy", args...)
 	cmd.Env = append(cmd.Env, fmt.Sprintf("GITHUB_TOKEN=%s", ghToken))
 
 	var output []byte
@@ -4,12 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
 	"os/exec"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/mergestat/mergestat/internal/db"
-	"github.com/mergestat/mergestat/internal/helper"
 )
 
 // handleYelpDetectSecretsRepoScan executes `detect-secrets scan` on a repo
@@ -17,19 +15,11 @@ import (
 func (w *worker) handleYelpDetectSecretsRepoScan(ctx context.Context, j *db.DequeueSyncJobRow) error {
 	l := w.loggerForJob(j)
 
-	tmpPath, cleanup, err := helper.CreateTempDir(os.Getenv("GIT_CLONE_PATH"), fmt.Sprintf("mergestat-repo-%s-*", j.RepoID.String()))
+	repoPath, release, err := w.checkoutWorktree(ctx, j)
 	if err != nil {
-		return fmt.Errorf("temp dir: %w", err)
-	}
-	defer func() {
-		if err := cleanup(); err != nil {
-			l.Err(err).Msgf("error cleaning up repo at: %s, %v", tmpPath, err)
-		}
-	}()
-
-	if err = w.clone(ctx, tmpPath, j); err != nil {
 		return fmt.Errorf("git clone: %w", err)
 	}
+	defer release()
 
 	// indicate that we're starting a yelp detect-secrets scan
 	if err := w.sendBatchLogMessages(ctx, []*syncLog{{Type: SyncLogTypeInfo, RepoSyncQueueID: j.ID,
@@ -39,7 +29,7 @@ func (w *worker) handleYelpDetectSecretsRepoScan(ctx context.Context, j *db.Dequ
 	}
 
 	cmd := exec.CommandContext(ctx, "detect-secrets", "scan")
-	cmd.Dir = tmpPath
+	cmd.Dir = repoPath
 
 	var output []byte
 	if output, err = cmd.Output(); err != nil {
@@ -26,11 +26,35 @@ func New(logger *zerolog.Logger, pool *pgxpool.Pool) *timeout {
 func (s *timeout) Start(ctx context.Context, interval time.Duration) {
 	s.logger.Info().Msg("starting timeout routine")
 	exec := func() {
+		if requeuedSyncJobIDs, err := s.db.RequeueOrphanedSyncJobs(ctx); err != nil {
+			s.logger.Err(err).Msg("encountered error re-queuing jobs of unresponsive workers")
+		} else if len(requeuedSyncJobIDs) > 0 {
+			s.logger.Warn().Msgf("re-queued %d sync job(s) of unresponsive workers", len(requeuedSyncJobIDs))
+		}
+
+		if n, err := s.db.DeleteDeadWorkers(ctx); err != nil {
+			s.logger.Err(err).Msg("encountered error removing unresponsive workers")
+		} else if n > 0 {
+			s.logger.Info().Msgf("removed %d unresponsive worker(s)", n)
+		}
+
 		if timedOutSyncJobIDs, err := s.db.MarkSyncsAsTimedOut(ctx); err != nil {
 			s.logger.Err(err).Msg("encountered error during job timeout execution")
 		} else if len(timedOutSyncJobIDs) > 0 {
 			s.logger.Info().Msgf("timed out %d sync job(s)", len(timedOutSyncJobIDs))
 		}
+
+		if n, err := s.db.EnqueueStrandedSyncJobs(ctx); err != nil {
+			s.logger.Err(err).Msg("encountered error enqueuing sync jobs whose sqlq job ended")
+		} else if n > 0 {
+			s.logger.Warn().Msgf("enqueued %d sync job(s) whose sqlq job ended without running them", n)
+		}
+
+		if failedSyncJobIDs, err := s.db.FailUnrunnableSyncJobs(ctx); err != nil {
+			s.logger.Err(err).Msg("encountered error failing sync jobs no worker can run")
+		} else if len(failedSyncJobIDs) > 0 {
+			s.logger.Warn().Msgf("failed %d sync job(s) no worker can run", len(failedSyncJobIDs))
+		}
 	}
 	exec()
 
@@ -50,7 +50,7 @@ func (w *warehouse) handleWorkflows(ctx context.Context, owner, repo string, job
 	var resp *github.Response
 	var workflowsPage *github.Workflows
 	repoID := job.RepoID
-	pagination, err := w.getPaginationOpt("GITHUB_WORKFLOW_PER_PAGE")
+	pagination, err := w.getPaginationOpt(job, "GITHUB_WORKFLOW_PER_PAGE", func(s *actionsSettings) int { return s.WorkflowsPerPage })
 	if err != nil {
 		return err
 	}
@@ -115,7 +115,7 @@ func (w *warehouse) handleWorkflowRuns(ctx context.Context, owner, repo string,
 	repoID := job.RepoID
 	runsCount := 0
 	jobsCount := 0
-	pagination, err := w.getPaginationOpt("GITHUB_WORKFLOW_RUNS_PER_PAGE")
+	pagination, err := w.getPaginationOpt(job, "GITHUB_WORKFLOW_RUNS_PER_PAGE", func(s *actionsSettings) int { return s.RunsPerPage })
 	if err != nil {
 		return err
 	}
@@ -218,7 +218,7 @@ func (w *warehouse) handleWorkflowRunsJobs(ctx context.Context, owner, repo stri
 	var resp *github.Response
 	var workflowRunJobsPage *github.Jobs
 	repoID := job.RepoID
-	pagination, err := w.getPaginationOpt("GITHUB_WORKFLOW_JOBS_PER_PAGE")
+	pagination, err := w.getPaginationOpt(job, "GITHUB_WORKFLOW_JOBS_PER_PAGE", func(s *actionsSettings) int { return s.JobsPerPage })
 	if err != nil {
 		return err
 	}
@@ -2,6 +2,7 @@ package warehouse
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
@@ -9,12 +10,13 @@ import (
 
 	"github.com/cavaliergopher/grab/v3"
 	"github.com/google/go-github/v50/github"
+	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/mergestat/mergestat/internal/db"
+	"github.com/mergestat/mergestat/internal/helper"
 	"github.com/mergestat/mergestat/internal/pool"
 	"github.com/mergestat/mergestat/queries"
 	"github.com/rs/zerolog"
-	"golang.org/x/oauth2"
 )
 
 type warehouse struct {
@@ -25,16 +27,7 @@ type warehouse struct {
 }
 
 func New(ctx context.Context, db *db.Queries, pgpool *pgxpool.Pool, logger *zerolog.Logger, ghToken string) *warehouse {
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: ghToken},
-	)
-
-	tc := oauth2.NewClient(ctx, ts)
-	if len(ghToken) <= 0 {
-		tc = nil
-	}
-
-	client := github.NewClient(tc)
+	client := helper.NewGitHubClient(ctx, ghToken)
 	pool := pool.Init(pgpool)
 	queries := queries.NewQuerier(db)
 
@@ -63,11 +56,29 @@ func (w *warehouse) parseJobLogs(logsUrl *url.URL, filepathDir string, n int) (s
 	return string(bytes), nil
 }
 
-// getPaginationOpt get the pagination env values for each workflow,runs and jobs
-// if these are not provided  will default to 30
-func (w *warehouse) getPaginationOpt(pagination string) (int, error) {
+// actionsSettings are the settings of a GITHUB_ACTIONS sync
+type actionsSettings struct {
+	WorkflowsPerPage int `json:"workflowsPerPage"`
+	RunsPerPage      int `json:"runsPerPage"`
+	JobsPerPage      int `json:"jobsPerPage"`
+}
+
+// getPaginationOpt get the pagination values for each workflow,runs and jobs from the settings of the sync,
+// falling back to the env values. if these are not provided  will default to 30
+func (w *warehouse) getPaginationOpt(job *db.DequeueSyncJobRow, pagination string, setting func(*actionsSettings) int) (int, error) {
 	var paginationEnv string
 
+	if job.Settings.Status == pgtype.Present {
+		var settings actionsSettings
+		if err := json.Unmarshal(job.Settings.Bytes, &settings); err != nil {
+			return 0, fmt.Errorf("parse sync settings: %w", err)
+		}
+
+		if perPage := setting(&settings); perPage > 0 {
+			return perPage, nil
+		}
+	}
+
 	if paginationEnv = os.Getenv(pagination); len(paginationEnv) <= 0 {
 		return 30, nil
 	}
@@ -0,0 +1,17 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS mergestat.repo_sync_checkpoints (
+    repo_sync_id UUID NOT NULL REFERENCES mergestat.repo_syncs(id) ON DELETE CASCADE,
+    ref TEXT NOT NULL,
+    commit_hash TEXT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    PRIMARY KEY (repo_sync_id, ref)
+);
+
+COMMENT ON TABLE mergestat.repo_sync_checkpoints IS 'Last synced commit per ref of a repo sync, used by git syncs to only process what changed since the previous run';
+COMMENT ON COLUMN mergestat.repo_sync_checkpoints.repo_sync_id IS 'foreign key for mergestat.repo_syncs.id';
+COMMENT ON COLUMN mergestat.repo_sync_checkpoints.ref IS 'fully qualified name of the ref that was synced';
+COMMENT ON COLUMN mergestat.repo_sync_checkpoints.commit_hash IS 'hash of the commit the ref pointed to when it was last synced';
+COMMENT ON COLUMN mergestat.repo_sync_checkpoints.updated_at IS 'timestamp of when the checkpoint was last updated';
+
+COMMIT;
@@ -0,0 +1,24 @@
+BEGIN;
+
+-- git syncs can be configured to sync refs other than HEAD (or several of them),
+-- so rows now record the ref they were synced from
+
+ALTER TABLE public.git_commits ADD COLUMN IF NOT EXISTS ref TEXT;
+COMMENT ON COLUMN public.git_commits.ref IS 'ref the commit was synced from (the first of the synced refs it is reachable from)';
+
+ALTER TABLE public.git_files ADD COLUMN IF NOT EXISTS ref TEXT NOT NULL DEFAULT 'HEAD';
+ALTER TABLE public.git_files ALTER COLUMN ref DROP DEFAULT;
+ALTER TABLE public.git_files DROP CONSTRAINT IF EXISTS files_pkey;
+ALTER TABLE public.git_files ADD CONSTRAINT files_pkey PRIMARY KEY (repo_id, ref, path);
+COMMENT ON COLUMN public.git_files.ref IS 'ref the file was synced from';
+
+ALTER TABLE public.git_blame ADD COLUMN IF NOT EXISTS ref TEXT NOT NULL DEFAULT 'HEAD';
+ALTER TABLE public.git_blame ALTER COLUMN ref DROP DEFAULT;
+ALTER TABLE public.git_blame DROP CONSTRAINT IF EXISTS git_blame_pkey;
+ALTER TABLE public.git_blame ADD CONSTRAINT git_blame_pkey PRIMARY KEY (repo_id, ref, path, line_no);
+COMMENT ON COLUMN public.git_blame.ref IS 'ref the blamed file was synced from';
+
+-- existing rows aren't attributed to the refs they came from, force a full reload on the next sync
+DELETE FROM mergestat.repo_sync_checkpoints;
+
+COMMIT;
@@ -0,0 +1,93 @@
+BEGIN;
+
+ALTER TABLE mergestat.repo_sync_types ADD COLUMN IF NOT EXISTS settings_schema JSONB;
+
+COMMENT ON COLUMN mergestat.repo_sync_types.settings_schema IS 'JSON schema the settings of syncs of this type (mergestat.repo_syncs.settings) are validated against before they are executed, NULL if the type has no settings';
+
+-- git syncs that walk the history of a repo can be configured with the refs to sync
+UPDATE mergestat.repo_sync_types SET settings_schema = '{
+    "type": "object",
+    "properties": {
+        "refs": {
+            "description": "names or globs of the branches and tags to sync, defaults to the ref configured on the repo or its default branch",
+            "type": "array",
+            "items": { "type": "string", "minLength": 1 }
+        }
+    },
+    "additionalProperties": false
+}'::jsonb WHERE type IN ('GIT_COMMITS', 'GIT_COMMIT_STATS', 'GIT_FILES');
+
+UPDATE mergestat.repo_sync_types SET settings_schema = '{
+    "type": "object",
+    "properties": {
+        "refs": {
+            "description": "names or globs of the branches and tags to sync, defaults to the ref configured on the repo or its default branch",
+            "type": "array",
+            "items": { "type": "string", "minLength": 1 }
+        },
+        "concurrency": {
+            "description": "number of files blamed in parallel, defaults to the number of CPUs of the worker",
+            "type": "integer",
+            "minimum": 1
+        },
+        "include": {
+            "description": "globs of the files to blame, all files are blamed if empty",
+            "type": "array",
+            "items": { "type": "string", "minLength": 1 }
+        },
+        "exclude": {
+            "description": "globs of the files not to blame",
+            "type": "array",
+            "items": { "type": "string", "minLength": 1 }
+        },
+        "maxFileSize": {
+            "description": "size in bytes above which files are not blamed",
+            "type": "integer",
+            "minimum": 1
+        }
+    },
+    "additionalProperties": false
+}'::jsonb WHERE type = 'GIT_BLAME';
+
+UPDATE mergestat.repo_sync_types SET settings_schema = '{
+    "type": "object",
+    "properties": {
+        "lookbackDays": {
+            "description": "only sync the pull requests updated in this many days, all pull requests are synced if not set",
+            "type": "integer",
+            "minimum": 1
+        },
+        "perPage": {
+            "description": "page size of the requests to the GitHub API",
+            "type": "integer",
+            "minimum": 1,
+            "maximum": 100
+        }
+    },
+    "additionalProperties": false
+}'::jsonb WHERE type = 'GITHUB_PRS_AND_COMMITS';
+
+UPDATE mergestat.repo_sync_types SET settings_schema = '{
+    "type": "object",
+    "properties": {
+        "workflowsPerPage": { "type": "integer", "minimum": 1, "maximum": 100 },
+        "runsPerPage": { "type": "integer", "minimum": 1, "maximum": 100 },
+        "jobsPerPage": { "type": "integer", "minimum": 1, "maximum": 100 }
+    },
+    "additionalProperties": false
+}'::jsonb WHERE type = 'GITHUB_ACTIONS';
+
+UPDATE mergestat.repo_sync_types SET settings_schema = '{
+    "type": "object",
+    "properties": {
+        "severity": {
+            "description": "severities of the vulnerabilities to report, all are reported if empty",
+            "type": "array",
+            "items": { "enum": ["UNKNOWN", "LOW", "MEDIUM", "HIGH", "CRITICAL"] },
+            "uniqueItems": true
+        }
+    },
+    "additionalProperties": false
+}'::jsonb WHERE type = 'TRIVY_REPO_SCAN';
+
+COMMIT;
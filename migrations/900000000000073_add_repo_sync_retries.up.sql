@@ -0,0 +1,77 @@
+BEGIN;
+
+INSERT INTO mergestat.repo_sync_queue_status_types (type, description) VALUES ('FAILED', 'Sync job failed after exhausting all of its attempts') ON CONFLICT DO NOTHING;
+
+-- retry policy of each sync type
+ALTER TABLE mergestat.repo_sync_types
+    ADD COLUMN IF NOT EXISTS retry_max_attempts INTEGER NOT NULL DEFAULT 3,
+    ADD COLUMN IF NOT EXISTS retry_backoff_base INTERVAL NOT NULL DEFAULT '1 minute'::interval,
+    ADD COLUMN IF NOT EXISTS retry_backoff_max INTERVAL NOT NULL DEFAULT '30 minutes'::interval,
+    ADD COLUMN IF NOT EXISTS retry_on TEXT[] NOT NULL DEFAULT '{network,database,github}';
+
+COMMENT ON COLUMN mergestat.repo_sync_types.retry_max_attempts IS 'maximum number of times a sync of this type is attempted before it is marked as FAILED, 1 disables retries';
+COMMENT ON COLUMN mergestat.repo_sync_types.retry_backoff_base IS 'delay before the first retry of a failed sync, doubled on every following attempt';
+COMMENT ON COLUMN mergestat.repo_sync_types.retry_backoff_max IS 'upper bound of the delay between two attempts of a sync';
+COMMENT ON COLUMN mergestat.repo_sync_types.retry_on IS 'classes of errors that are retried: network (connection errors, timeouts), database (connection loss, serialization failures, deadlocks), github (server errors, rate limits)';
+
+-- attempts of each job
+ALTER TABLE mergestat.repo_sync_queue
+    ADD COLUMN IF NOT EXISTS attempt INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS run_after TIMESTAMP WITH TIME ZONE;
+
+COMMENT ON COLUMN mergestat.repo_sync_queue.attempt IS 'number of the current attempt of the job, starting at 1';
+COMMENT ON COLUMN mergestat.repo_sync_queue.run_after IS 'timestamp before which the job is not dequeued, set when a failed attempt is retried with a backoff';
+
+CREATE INDEX IF NOT EXISTS idx_repo_sync_queue_run_after ON mergestat.repo_sync_queue (run_after) WHERE status = 'QUEUED';
+
+-- a failed job is done as well
+CREATE OR REPLACE FUNCTION public.repo_sync_queue_status_update_trigger() RETURNS trigger
+LANGUAGE plpgsql
+AS $$
+BEGIN
+	IF NEW.status = 'RUNNING' AND OLD.status = 'QUEUED' THEN
+		NEW.started_at = now();
+	ELSEIF NEW.status IN ('DONE', 'FAILED') AND OLD.status = 'RUNNING' THEN
+		NEW.done_at = now();
+	END IF;
+	RETURN NEW;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION mergestat.set_sync_job_status(new_status TEXT, repo_sync_queue_id BIGINT)
+RETURNS UUID
+AS
+$$
+DECLARE _repo_sync_id UUID;
+BEGIN
+    IF new_status IN ('DONE', 'FAILED') THEN
+            WITH update_queue AS (
+                UPDATE mergestat.repo_sync_queue SET "status" = new_status WHERE mergestat.repo_sync_queue.id = repo_sync_queue_id
+                RETURNING *
+            )
+            UPDATE mergestat.repo_syncs set last_completed_repo_sync_queue_id = repo_sync_queue_id
+            FROM update_queue
+            WHERE mergestat.repo_syncs.id = update_queue.repo_sync_id
+            RETURNING mergestat.repo_syncs.id INTO _repo_sync_id;
+    ELSE
+            UPDATE mergestat.repo_sync_queue SET "status" = new_status WHERE mergestat.repo_sync_queue.id = repo_sync_queue_id
+            RETURNING repo_sync_id INTO _repo_sync_id;
+    END IF;
+
+    RETURN _repo_sync_id;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE VIEW mergestat.latest_repo_syncs AS
+SELECT DISTINCT ON (repo_sync_queue.repo_sync_id)
+    repo_sync_queue.id,
+    repo_sync_queue.created_at,
+    repo_sync_queue.repo_sync_id,
+    repo_sync_queue.status,
+    repo_sync_queue.started_at,
+    repo_sync_queue.done_at
+FROM mergestat.repo_sync_queue
+WHERE (repo_sync_queue.status IN ('DONE', 'FAILED'))
+ORDER BY repo_sync_queue.repo_sync_id ASC, repo_sync_queue.created_at DESC;
+
+COMMIT;
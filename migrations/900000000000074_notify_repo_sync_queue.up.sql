@@ -0,0 +1,24 @@
+BEGIN;
+
+-- notify listening workers (see internal/syncer/listen.go) whenever a job is queued, so that they don't have to poll for it.
+-- This covers every path enqueuing repo syncs: mergestat.repo_sync_queue is written to by EnqueueAllSyncs (the scheduler),
+-- repo imports and the "sync now" action of the app, as well as re-queued jobs. Retries scheduled in the future are
+-- left to the polling fallback of the workers.
+CREATE OR REPLACE FUNCTION mergestat.notify_repo_sync_queue() RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    IF NEW.status = 'QUEUED' AND (NEW.run_after IS NULL OR NEW.run_after <= now()) THEN
+        PERFORM pg_notify('mergestat_repo_sync_queue', NEW.id::TEXT);
+    END IF;
+    RETURN NULL;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS repo_sync_queue_notify_trigger ON mergestat.repo_sync_queue;
+CREATE TRIGGER repo_sync_queue_notify_trigger AFTER INSERT OR UPDATE OF status ON mergestat.repo_sync_queue
+FOR EACH ROW EXECUTE FUNCTION mergestat.notify_repo_sync_queue();
+
+COMMENT ON FUNCTION mergestat.notify_repo_sync_queue() IS 'Sends a notification on the mergestat_repo_sync_queue channel, with the ID of the job as payload, when a repo sync job is queued';
+
+COMMIT;
@@ -0,0 +1,50 @@
+BEGIN;
+
+INSERT INTO mergestat.repo_sync_queue_status_types (type, description) VALUES ('CANCELED', 'Sync job was canceled') ON CONFLICT DO NOTHING;
+
+ALTER TABLE mergestat.repo_sync_queue ADD COLUMN IF NOT EXISTS cancel_requested_at TIMESTAMP WITH TIME ZONE;
+
+COMMENT ON COLUMN mergestat.repo_sync_queue.cancel_requested_at IS 'timestamp of when the cancellation of the job was requested, the job is canceled by its worker if it''s running';
+
+-- a canceled job is done as well
+CREATE OR REPLACE FUNCTION public.repo_sync_queue_status_update_trigger() RETURNS trigger
+LANGUAGE plpgsql
+AS $$
+BEGIN
+	IF NEW.status = 'RUNNING' AND OLD.status = 'QUEUED' THEN
+		NEW.started_at = now();
+	ELSEIF NEW.status IN ('DONE', 'FAILED') AND OLD.status = 'RUNNING' THEN
+		NEW.done_at = now();
+	ELSEIF NEW.status = 'CANCELED' AND OLD.status IN ('QUEUED', 'RUNNING') THEN
+		NEW.done_at = now();
+	END IF;
+	RETURN NEW;
+END;
+$$;
+
+-- cancel_repo_sync_job cancels a queued job right away. A running job is flagged for cancellation, and is
+-- canceled by the worker running it the next time it sends a keep alive. Returns false if the job is already done.
+CREATE OR REPLACE FUNCTION mergestat.cancel_repo_sync_job(repo_sync_queue_id BIGINT)
+RETURNS BOOLEAN
+LANGUAGE plpgsql
+AS $$
+DECLARE _status TEXT;
+BEGIN
+    SELECT status INTO _status FROM mergestat.repo_sync_queue WHERE id = repo_sync_queue_id FOR UPDATE;
+
+    IF _status = 'QUEUED' THEN
+        UPDATE mergestat.repo_sync_queue SET status = 'CANCELED', cancel_requested_at = now() WHERE id = repo_sync_queue_id;
+        INSERT INTO mergestat.repo_sync_logs (repo_sync_queue_id, log_type, message) VALUES (repo_sync_queue_id, 'WARNING', 'sync was canceled before it started');
+        RETURN TRUE;
+    ELSEIF _status = 'RUNNING' THEN
+        UPDATE mergestat.repo_sync_queue SET cancel_requested_at = now() WHERE id = repo_sync_queue_id AND cancel_requested_at IS NULL;
+        RETURN TRUE;
+    END IF;
+
+    RETURN FALSE;
+END;
+$$;
+
+COMMENT ON FUNCTION mergestat.cancel_repo_sync_job(BIGINT) IS 'Cancels a queued or running repo sync job';
+
+COMMIT;
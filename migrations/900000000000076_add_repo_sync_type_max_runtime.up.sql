@@ -0,0 +1,47 @@
+BEGIN;
+
+ALTER TABLE mergestat.repo_sync_types ADD COLUMN IF NOT EXISTS max_runtime INTERVAL;
+
+COMMENT ON COLUMN mergestat.repo_sync_types.max_runtime IS 'maximum time a sync of this type may run for before it''s canceled by its worker and marked as FAILED, NULL if unlimited';
+
+-- scans already run with a timeout of 30 minutes (see internal/syncer/trivy_repo_scan.go), leave some room to store the results
+UPDATE mergestat.repo_sync_types SET max_runtime = '1 hour'::interval WHERE type = 'TRIVY_REPO_SCAN' AND max_runtime IS NULL;
+
+-- a job that isn't running anymore (e.g. it was timed out by mergestat.repo_sync_queue) can't be marked as DONE. The
+-- exception aborts the transaction of the handler, so that a job still running past its timeout doesn't commit its results.
+-- Other transitions out of a status other than RUNNING are ignored.
+CREATE OR REPLACE FUNCTION mergestat.set_sync_job_status(new_status TEXT, repo_sync_queue_id BIGINT)
+RETURNS UUID
+AS
+$$
+DECLARE _repo_sync_id UUID;
+DECLARE _status TEXT;
+BEGIN
+    SELECT status INTO _status FROM mergestat.repo_sync_queue WHERE mergestat.repo_sync_queue.id = repo_sync_queue_id FOR UPDATE;
+
+    IF _status IS DISTINCT FROM 'RUNNING' THEN
+        IF new_status = 'DONE' THEN
+            RAISE EXCEPTION 'sync job % is not running anymore (status: %)', repo_sync_queue_id, _status;
+        END IF;
+        RETURN NULL;
+    END IF;
+
+    IF new_status IN ('DONE', 'FAILED') THEN
+            WITH update_queue AS (
+                UPDATE mergestat.repo_sync_queue SET "status" = new_status WHERE mergestat.repo_sync_queue.id = repo_sync_queue_id
+                RETURNING *
+            )
+            UPDATE mergestat.repo_syncs set last_completed_repo_sync_queue_id = repo_sync_queue_id
+            FROM update_queue
+            WHERE mergestat.repo_syncs.id = update_queue.repo_sync_id
+            RETURNING mergestat.repo_syncs.id INTO _repo_sync_id;
+    ELSE
+            UPDATE mergestat.repo_sync_queue SET "status" = new_status WHERE mergestat.repo_sync_queue.id = repo_sync_queue_id
+            RETURNING repo_sync_id INTO _repo_sync_id;
+    END IF;
+
+    RETURN _repo_sync_id;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
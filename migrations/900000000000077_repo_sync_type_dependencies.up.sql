@@ -0,0 +1,110 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS mergestat.repo_sync_type_dependencies (
+    sync_type TEXT NOT NULL REFERENCES mergestat.repo_sync_types(type) ON UPDATE RESTRICT ON DELETE CASCADE,
+    depends_on TEXT NOT NULL REFERENCES mergestat.repo_sync_types(type) ON UPDATE RESTRICT ON DELETE CASCADE,
+    CONSTRAINT repo_sync_type_dependencies_pkey PRIMARY KEY (sync_type, depends_on),
+    CONSTRAINT repo_sync_type_dependencies_not_self CHECK (sync_type <> depends_on)
+);
+
+COMMENT ON TABLE mergestat.repo_sync_type_dependencies IS 'Dependencies between sync types. On every scheduling cycle, a sync of a repo is enqueued only after the enabled syncs of the types it depends on for the same repo complete successfully';
+COMMENT ON COLUMN mergestat.repo_sync_type_dependencies.sync_type IS 'the dependent sync type';
+COMMENT ON COLUMN mergestat.repo_sync_type_dependencies.depends_on IS 'the sync type that must complete before syncs of the dependent type are enqueued';
+
+CREATE INDEX IF NOT EXISTS idx_repo_sync_type_dependencies_depends_on ON mergestat.repo_sync_type_dependencies(depends_on);
+
+-- dependencies must form a DAG, reject any dependency introducing a cycle
+CREATE OR REPLACE FUNCTION mergestat.check_repo_sync_type_dependencies() RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    IF EXISTS (
+        WITH RECURSIVE prerequisites AS (
+            SELECT depends_on FROM mergestat.repo_sync_type_dependencies WHERE sync_type = NEW.depends_on
+            UNION
+            SELECT d.depends_on FROM mergestat.repo_sync_type_dependencies d INNER JOIN prerequisites p ON d.sync_type = p.depends_on
+        )
+        SELECT 1 FROM prerequisites WHERE depends_on = NEW.sync_type
+    ) THEN
+        RAISE EXCEPTION 'dependency of % on % introduces a cycle', NEW.sync_type, NEW.depends_on;
+    END IF;
+    RETURN NEW;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS repo_sync_type_dependencies_check_trigger ON mergestat.repo_sync_type_dependencies;
+CREATE TRIGGER repo_sync_type_dependencies_check_trigger BEFORE INSERT OR UPDATE ON mergestat.repo_sync_type_dependencies
+FOR EACH ROW EXECUTE FUNCTION mergestat.check_repo_sync_type_dependencies();
+
+INSERT INTO mergestat.repo_sync_type_dependencies (sync_type, depends_on) VALUES
+    ('GIT_COMMIT_STATS', 'GIT_COMMITS'),
+    ('GITHUB_PR_REVIEWS', 'GITHUB_REPO_PRS'),
+    ('GITHUB_PR_COMMITS', 'GITHUB_REPO_PRS'),
+    ('OSSF_SCORECARD_REPO_SCAN', 'GITHUB_REPO_METADATA')
+ON CONFLICT DO NOTHING;
+
+-- reports whether the enabled prerequisites of a sync are all done, i.e. the latest job of each of them is DONE
+-- and was created after the latest job of the sync. A sync without enabled prerequisites has them all done.
+CREATE OR REPLACE FUNCTION mergestat.repo_sync_prerequisites_done(repo_sync_id UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE
+AS $$
+    SELECT NOT EXISTS (
+        SELECT 1
+        FROM mergestat.repo_syncs dependent
+        INNER JOIN mergestat.repo_sync_type_dependencies pd ON pd.sync_type = dependent.sync_type
+        INNER JOIN mergestat.repo_syncs prerequisite ON prerequisite.repo_id = dependent.repo_id AND prerequisite.sync_type = pd.depends_on
+        LEFT JOIN LATERAL (
+            SELECT q.status, q.created_at FROM mergestat.repo_sync_queue q
+            WHERE q.repo_sync_id = prerequisite.id ORDER BY q.created_at DESC LIMIT 1
+        ) latest ON true
+        WHERE dependent.id = $1
+            AND prerequisite.schedule_enabled
+            AND (latest.status IS DISTINCT FROM 'DONE' OR latest.created_at < (
+                SELECT max(q.created_at) FROM mergestat.repo_sync_queue q WHERE q.repo_sync_id = dependent.id
+            ))
+    )
+$$;
+
+COMMENT ON FUNCTION mergestat.repo_sync_prerequisites_done(UUID) IS 'Reports whether the enabled prerequisites of a sync (see mergestat.repo_sync_type_dependencies) are all done since the sync last ran';
+
+-- when a sync completes successfully, enqueue the enabled syncs of the same repo depending on it whose prerequisites
+-- are now all done. EnqueueAllSyncs only enqueues the syncs whose prerequisites are all done as well.
+CREATE OR REPLACE FUNCTION mergestat.enqueue_dependent_syncs() RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    -- lock the dependents first, so that prerequisites completing concurrently see each other's status below
+    -- (each statement runs with a new snapshot) and exactly one of them enqueues the dependent
+    PERFORM dependent.id
+    FROM mergestat.repo_syncs completed
+    INNER JOIN mergestat.repo_sync_type_dependencies d ON d.depends_on = completed.sync_type
+    INNER JOIN mergestat.repo_syncs dependent ON dependent.repo_id = completed.repo_id AND dependent.sync_type = d.sync_type
+    WHERE completed.id = NEW.repo_sync_id AND dependent.schedule_enabled
+    ORDER BY dependent.id
+    FOR UPDATE OF dependent;
+
+    INSERT INTO mergestat.repo_sync_queue (repo_sync_id, status, priority, type_group)
+    SELECT dependent.id, 'QUEUED', dependent.priority, rst.type_group
+    FROM mergestat.repo_syncs completed
+    INNER JOIN mergestat.repo_sync_type_dependencies d ON d.depends_on = completed.sync_type
+    INNER JOIN mergestat.repo_syncs dependent ON dependent.repo_id = completed.repo_id AND dependent.sync_type = d.sync_type
+    INNER JOIN mergestat.repo_sync_types rst ON rst.type = dependent.sync_type
+    WHERE completed.id = NEW.repo_sync_id
+        AND dependent.schedule_enabled
+        AND NOT EXISTS (
+            SELECT 1 FROM mergestat.repo_sync_queue q WHERE q.repo_sync_id = dependent.id AND q.status IN ('QUEUED', 'RUNNING')
+        )
+        AND mergestat.repo_sync_prerequisites_done(dependent.id);
+
+    RETURN NULL;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS repo_sync_queue_enqueue_dependents_trigger ON mergestat.repo_sync_queue;
+CREATE TRIGGER repo_sync_queue_enqueue_dependents_trigger AFTER UPDATE OF status ON mergestat.repo_sync_queue
+FOR EACH ROW WHEN (NEW.status = 'DONE' AND OLD.status IS DISTINCT FROM 'DONE')
+EXECUTE FUNCTION mergestat.enqueue_dependent_syncs();
+
+COMMENT ON FUNCTION mergestat.enqueue_dependent_syncs() IS 'Enqueues the syncs depending on a sync that completed successfully, once all of their prerequisites are done';
+
+COMMIT;
@@ -0,0 +1,56 @@
+BEGIN;
+
+ALTER TABLE mergestat.repo_sync_types ADD COLUMN IF NOT EXISTS schedule TEXT;
+ALTER TABLE mergestat.repo_sync_types ADD COLUMN IF NOT EXISTS min_interval INTERVAL;
+
+COMMENT ON COLUMN mergestat.repo_sync_types.schedule IS 'default cron expression (e.g. "0 3 * * *" or "@daily") of the times syncs of this type are due to run, NULL to run them on every scheduling cycle';
+COMMENT ON COLUMN mergestat.repo_sync_types.min_interval IS 'default minimum time between two runs of a sync of this type, NULL if unlimited';
+
+ALTER TABLE mergestat.repo_syncs ADD COLUMN IF NOT EXISTS schedule TEXT;
+ALTER TABLE mergestat.repo_syncs ADD COLUMN IF NOT EXISTS min_interval INTERVAL;
+
+COMMENT ON COLUMN mergestat.repo_syncs.schedule IS 'cron expression of the times the sync is due to run, overriding the default of its sync type if not NULL. Syncs depending on other syncs (see mergestat.repo_sync_type_dependencies) run once due and their prerequisites are done';
+COMMENT ON COLUMN mergestat.repo_syncs.min_interval IS 'minimum time between two runs of the sync, overriding the default of its sync type if not NULL';
+
+-- syncs depending on other syncs are subject to the same schedule and minimum interval as the other syncs: the
+-- scheduler enqueues them once they're due and their prerequisites are done, and syncs without a cron schedule are
+-- also enqueued as soon as their last prerequisite completes, if their minimum interval since they were last
+-- enqueued has elapsed
+CREATE OR REPLACE FUNCTION mergestat.enqueue_dependent_syncs() RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    -- lock the dependents first, so that prerequisites completing concurrently see each other's status below
+    -- (each statement runs with a new snapshot) and exactly one of them enqueues the dependent
+    PERFORM dependent.id
+    FROM mergestat.repo_syncs completed
+    INNER JOIN mergestat.repo_sync_type_dependencies d ON d.depends_on = completed.sync_type
+    INNER JOIN mergestat.repo_syncs dependent ON dependent.repo_id = completed.repo_id AND dependent.sync_type = d.sync_type
+    WHERE completed.id = NEW.repo_sync_id AND dependent.schedule_enabled
+    ORDER BY dependent.id
+    FOR UPDATE OF dependent;
+
+    INSERT INTO mergestat.repo_sync_queue (repo_sync_id, status, priority, type_group)
+    SELECT dependent.id, 'QUEUED', dependent.priority, rst.type_group
+    FROM mergestat.repo_syncs completed
+    INNER JOIN mergestat.repo_sync_type_dependencies d ON d.depends_on = completed.sync_type
+    INNER JOIN mergestat.repo_syncs dependent ON dependent.repo_id = completed.repo_id AND dependent.sync_type = d.sync_type
+    INNER JOIN mergestat.repo_sync_types rst ON rst.type = dependent.sync_type
+    WHERE completed.id = NEW.repo_sync_id
+        AND dependent.schedule_enabled
+        AND COALESCE(dependent.schedule, rst.schedule) IS NULL
+        AND NOT EXISTS (
+            SELECT 1 FROM mergestat.repo_sync_queue q WHERE q.repo_sync_id = dependent.id AND (
+                q.status IN ('QUEUED', 'RUNNING')
+                OR q.created_at > now() - COALESCE(dependent.min_interval, rst.min_interval)
+            )
+        )
+        AND mergestat.repo_sync_prerequisites_done(dependent.id);
+
+    RETURN NULL;
+END;
+$$;
+
+COMMENT ON FUNCTION mergestat.enqueue_dependent_syncs() IS 'Enqueues the syncs without a cron schedule depending on a sync that completed successfully, once all of their prerequisites are done';
+
+COMMIT;
@@ -0,0 +1,70 @@
+BEGIN;
+
+ALTER TABLE mergestat.repo_sync_types ADD COLUMN IF NOT EXISTS window_start TIME;
+ALTER TABLE mergestat.repo_sync_types ADD COLUMN IF NOT EXISTS window_end TIME;
+ALTER TABLE mergestat.repo_sync_types ADD COLUMN IF NOT EXISTS window_timezone TEXT NOT NULL DEFAULT 'UTC';
+ALTER TABLE mergestat.repo_sync_types ADD COLUMN IF NOT EXISTS jitter INTERVAL;
+
+-- reject unknown time zones, which would make the scheduler fail to compute whether syncs are in their window
+ALTER TABLE mergestat.repo_sync_types DROP CONSTRAINT IF EXISTS repo_sync_types_window_timezone_check;
+ALTER TABLE mergestat.repo_sync_types ADD CONSTRAINT repo_sync_types_window_timezone_check CHECK ('2000-01-01 00:00:00+00'::TIMESTAMPTZ AT TIME ZONE window_timezone IS NOT NULL);
+
+COMMENT ON COLUMN mergestat.repo_sync_types.window_start IS 'start of the daily time window (in window_timezone) syncs of this type may be scheduled in, NULL to schedule them at any time. The window wraps around midnight if it ends before it starts (e.g. 22:00 to 06:00)';
+COMMENT ON COLUMN mergestat.repo_sync_types.window_end IS 'end of the daily time window (in window_timezone) syncs of this type may be scheduled in, NULL to schedule them at any time';
+COMMENT ON COLUMN mergestat.repo_sync_types.window_timezone IS 'time zone of window_start and window_end, e.g. America/New_York';
+COMMENT ON COLUMN mergestat.repo_sync_types.jitter IS 'maximum random delay applied to syncs of this type when they''re scheduled, to spread them over time instead of running them all at once. NULL to run them right away';
+
+-- reports whether the current time is in the daily time window syncs of the given type may be scheduled in
+CREATE OR REPLACE FUNCTION mergestat.repo_sync_type_in_window(sync_type TEXT) RETURNS BOOLEAN
+LANGUAGE sql STABLE
+AS $$
+    SELECT
+        rst.window_start IS NULL OR rst.window_end IS NULL
+        OR (rst.window_start <= rst.window_end AND (now() AT TIME ZONE rst.window_timezone)::TIME BETWEEN rst.window_start AND rst.window_end)
+        OR (rst.window_start > rst.window_end AND ((now() AT TIME ZONE rst.window_timezone)::TIME >= rst.window_start OR (now() AT TIME ZONE rst.window_timezone)::TIME <= rst.window_end))
+    FROM mergestat.repo_sync_types rst WHERE rst.type = $1
+$$;
+
+COMMENT ON FUNCTION mergestat.repo_sync_type_in_window(TEXT) IS 'Reports whether the current time is in the daily time window syncs of the given type may be scheduled in';
+
+-- syncs depending on other syncs are only enqueued in the time window of their sync type as well, and like the
+-- scheduler, their jobs are delayed by a random jitter of up to the one of their sync type
+CREATE OR REPLACE FUNCTION mergestat.enqueue_dependent_syncs() RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    -- lock the dependents first, so that prerequisites completing concurrently see each other's status below
+    -- (each statement runs with a new snapshot) and exactly one of them enqueues the dependent
+    PERFORM dependent.id
+    FROM mergestat.repo_syncs completed
+    INNER JOIN mergestat.repo_sync_type_dependencies d ON d.depends_on = completed.sync_type
+    INNER JOIN mergestat.repo_syncs dependent ON dependent.repo_id = completed.repo_id AND dependent.sync_type = d.sync_type
+    WHERE completed.id = NEW.repo_sync_id AND dependent.schedule_enabled
+    ORDER BY dependent.id
+    FOR UPDATE OF dependent;
+
+    INSERT INTO mergestat.repo_sync_queue (repo_sync_id, status, priority, type_group, run_after)
+    SELECT dependent.id, 'QUEUED', dependent.priority, rst.type_group, now() + random() * rst.jitter
+    FROM mergestat.repo_syncs completed
+    INNER JOIN mergestat.repo_sync_type_dependencies d ON d.depends_on = completed.sync_type
+    INNER JOIN mergestat.repo_syncs dependent ON dependent.repo_id = completed.repo_id AND dependent.sync_type = d.sync_type
+    INNER JOIN mergestat.repo_sync_types rst ON rst.type = dependent.sync_type
+    WHERE completed.id = NEW.repo_sync_id
+        AND dependent.schedule_enabled
+        AND COALESCE(dependent.schedule, rst.schedule) IS NULL
+        AND mergestat.repo_sync_type_in_window(dependent.sync_type)
+        AND NOT EXISTS (
+            SELECT 1 FROM mergestat.repo_sync_queue q WHERE q.repo_sync_id = dependent.id AND (
+                q.status IN ('QUEUED', 'RUNNING')
+                OR q.created_at > now() - COALESCE(dependent.min_interval, rst.min_interval)
+            )
+        )
+        AND mergestat.repo_sync_prerequisites_done(dependent.id);
+
+    RETURN NULL;
+END;
+$$;
+
+COMMENT ON FUNCTION mergestat.enqueue_dependent_syncs() IS 'Enqueues the syncs without a cron schedule depending on a sync that completed successfully, once all of their prerequisites are done';
+
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS mergestat.workers (
+    id UUID PRIMARY KEY,
+    hostname TEXT NOT NULL,
+    capabilities TEXT[] NOT NULL DEFAULT '{}',
+    sync_types TEXT[] NOT NULL DEFAULT '{}',
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+COMMENT ON TABLE mergestat.workers IS 'Workers processing repo syncs, with the capabilities they advertise. A worker only dequeues jobs of the sync types it registered';
+COMMENT ON COLUMN mergestat.workers.hostname IS 'hostname of the machine or container the worker runs on';
+COMMENT ON COLUMN mergestat.workers.capabilities IS 'capabilities available to the worker, e.g. clone or executable:trivy';
+COMMENT ON COLUMN mergestat.workers.sync_types IS 'sync types the worker can run: the ones it has a handler for, whose required capabilities are available, and that are allowed by its configuration';
+COMMENT ON COLUMN mergestat.workers.started_at IS 'time the worker started at';
+
+COMMIT;
@@ -0,0 +1,36 @@
+BEGIN;
+
+ALTER TABLE mergestat.workers ADD COLUMN IF NOT EXISTS version TEXT;
+ALTER TABLE mergestat.workers ADD COLUMN IF NOT EXISTS concurrency INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE mergestat.workers ADD COLUMN IF NOT EXISTS last_heartbeat_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+COMMENT ON COLUMN mergestat.workers.version IS 'version of the worker binary';
+COMMENT ON COLUMN mergestat.workers.concurrency IS 'number of exec loops of the worker, i.e. of jobs it runs concurrently';
+COMMENT ON COLUMN mergestat.workers.last_heartbeat_at IS 'last time the worker reported it was alive. The running jobs of workers without a recent heartbeat are re-queued, and the workers removed after a while';
+
+ALTER TABLE mergestat.repo_sync_queue ADD COLUMN IF NOT EXISTS worker_id UUID;
+ALTER TABLE mergestat.repo_sync_queue ADD COLUMN IF NOT EXISTS worker_exec_loop INTEGER;
+
+COMMENT ON COLUMN mergestat.repo_sync_queue.worker_id IS 'ID of the worker (see mergestat.workers) that dequeued the job last';
+COMMENT ON COLUMN mergestat.repo_sync_queue.worker_exec_loop IS 'exec loop of the worker running the job, from 0 to the concurrency of the worker - 1';
+
+CREATE INDEX IF NOT EXISTS idx_repo_sync_queue_worker_id_running ON mergestat.repo_sync_queue(worker_id) WHERE status = 'RUNNING';
+
+CREATE OR REPLACE VIEW mergestat.worker_exec_loops AS
+SELECT
+    w.id AS worker_id,
+    w.hostname,
+    w.last_heartbeat_at,
+    exec_loop.n AS exec_loop,
+    rsq.id AS repo_sync_queue_id,
+    rs.sync_type,
+    rs.repo_id,
+    rsq.started_at
+FROM mergestat.workers w
+CROSS JOIN LATERAL generate_series(0, w.concurrency - 1) AS exec_loop(n)
+LEFT JOIN mergestat.repo_sync_queue rsq ON rsq.worker_id = w.id AND rsq.worker_exec_loop = exec_loop.n AND rsq.status = 'RUNNING'
+LEFT JOIN mergestat.repo_syncs rs ON rs.id = rsq.repo_sync_id;
+
+COMMENT ON VIEW mergestat.worker_exec_loops IS 'Exec loops of the registered workers, with the job each of them is running, if any';
+
+COMMIT;
@@ -0,0 +1,168 @@
+BEGIN;
+
+-- repo syncs are executed as sqlq jobs: every queued job of mergestat.repo_sync_queue is backed by a job of type
+-- repos/sync/<sync type> in sqlq.jobs, in a queue per type group and provider. The repo_sync_queue job keeps track
+-- of the status of the sync (and its logs, in mergestat.repo_sync_logs), while sqlq dispatches it to the workers
+-- able to run it, enforcing the priorities and concurrency of the queues and the keep-alives of the job.
+ALTER TABLE mergestat.repo_sync_queue ADD COLUMN IF NOT EXISTS sqlq_job_id UUID REFERENCES sqlq.jobs(id) ON DELETE SET NULL;
+
+COMMENT ON COLUMN mergestat.repo_sync_queue.sqlq_job_id IS 'ID of the sqlq job executing the job (see mergestat.enqueue_repo_sync_job)';
+
+-- sync_type_runnable returns false if workers are alive but none of them can run syncs of the type (e.g. it lacks
+-- the capabilities they require, or the type isn't allowed by the configuration of the workers). Without any live
+-- worker, all types are considered runnable, the jobs waiting for workers to start.
+CREATE OR REPLACE FUNCTION mergestat.sync_type_runnable(sync_type TEXT) RETURNS BOOLEAN
+LANGUAGE SQL STABLE
+AS $$
+    SELECT NOT EXISTS (SELECT 1 FROM mergestat.workers w WHERE w.last_heartbeat_at >= now() - '2 minutes'::interval)
+        OR EXISTS (SELECT 1 FROM mergestat.workers w WHERE w.last_heartbeat_at >= now() - '2 minutes'::interval AND $1 = ANY(w.sync_types));
+$$;
+
+COMMENT ON FUNCTION mergestat.sync_type_runnable(TEXT) IS 'Returns false if workers are alive but none of them can run syncs of the given type';
+
+-- enqueue_repo_sync_job creates the sqlq job executing the given repo sync job, and returns its ID. The queue of the
+-- job is created if it doesn't exist yet, and its concurrency kept in sync with the concurrent syncs of the type group.
+-- Failed attempts are retried by queuing the job again, with the backoff of its sync type, so sqlq never retries them
+-- itself. A job no worker can run is marked as FAILED rather than being enqueued, as it would stay queued forever.
+CREATE OR REPLACE FUNCTION mergestat.enqueue_repo_sync_job(job mergestat.repo_sync_queue) RETURNS UUID
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    _queue TEXT;
+    _concurrency INTEGER;
+    _sync_type TEXT;
+    _sqlq_job_id UUID;
+BEGIN
+    SELECT 'syncs-' || lower(job.type_group) || '-' || COALESCE(r.provider::TEXT, 'default'), rstg.concurrent_syncs, rs.sync_type
+    INTO _queue, _concurrency, _sync_type
+    FROM mergestat.repo_syncs rs
+    INNER JOIN mergestat.repo_sync_type_groups rstg ON rstg.group = job.type_group
+    INNER JOIN public.repos r ON r.id = rs.repo_id
+    WHERE rs.id = job.repo_sync_id;
+
+    IF NOT mergestat.sync_type_runnable(_sync_type) THEN
+        UPDATE mergestat.repo_sync_queue SET status = 'FAILED', done_at = now() WHERE id = job.id;
+        INSERT INTO mergestat.repo_sync_logs (repo_sync_queue_id, log_type, message)
+        VALUES (job.id, 'ERROR', 'no worker can run syncs of type ' || _sync_type || ', see the sync types of the workers in mergestat.workers');
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO sqlq.queues (name, concurrency, priority)
+    VALUES (_queue, _concurrency, CASE WHEN job.type_group = 'GITHUB' THEN 1 ELSE 2 END)
+    ON CONFLICT (name) DO UPDATE SET concurrency = excluded.concurrency, priority = excluded.priority
+    WHERE (queues.concurrency, queues.priority) IS DISTINCT FROM (excluded.concurrency, excluded.priority);
+
+    -- run_after of sqlq jobs is a delay in nanoseconds
+    INSERT INTO sqlq.jobs (queue, typename, parameters, priority, max_retries, run_after)
+    VALUES (_queue, 'repos/sync/' || _sync_type, json_build_object('id', job.id), job.priority, 1,
+        (GREATEST(EXTRACT(EPOCH FROM job.run_after - now()), 0) * 1e9)::BIGINT)
+    RETURNING id INTO _sqlq_job_id;
+
+    RETURN _sqlq_job_id;
+END;
+$$;
+
+COMMENT ON FUNCTION mergestat.enqueue_repo_sync_job(mergestat.repo_sync_queue) IS 'Creates the sqlq job executing a repo sync job, in the queue of its type group and provider. Marks the job as FAILED, and returns NULL, if no live worker can run it';
+
+-- a repo sync job gets a new sqlq job every time it's queued, be it on insert or when it's queued again (e.g. to retry
+-- it after a failed attempt, or when the worker running it shut down or died). The sqlq job is created after the row
+-- is written, so that enqueue_repo_sync_job can refer to it (e.g. to log why the job can't be executed).
+CREATE OR REPLACE FUNCTION mergestat.repo_sync_queue_enqueue_sqlq_job() RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    _sqlq_job_id UUID;
+BEGIN
+    IF NEW.status = 'QUEUED' AND (TG_OP = 'INSERT' OR OLD.status IS DISTINCT FROM 'QUEUED') THEN
+        _sqlq_job_id := mergestat.enqueue_repo_sync_job(NEW);
+        IF _sqlq_job_id IS NOT NULL THEN
+            UPDATE mergestat.repo_sync_queue SET sqlq_job_id = _sqlq_job_id WHERE id = NEW.id;
+        END IF;
+    END IF;
+    RETURN NULL;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS repo_sync_queue_enqueue_sqlq_job_trigger ON mergestat.repo_sync_queue;
+CREATE TRIGGER repo_sync_queue_enqueue_sqlq_job_trigger AFTER INSERT OR UPDATE OF status ON mergestat.repo_sync_queue
+FOR EACH ROW EXECUTE FUNCTION mergestat.repo_sync_queue_enqueue_sqlq_job();
+
+-- workers are notified once the sqlq job of a queued job is created, rather than when the job is queued, so that they
+-- don't wake up for jobs that aren't enqueued in sqlq (e.g. the ones no worker can run, marked as FAILED instead)
+CREATE OR REPLACE FUNCTION mergestat.notify_repo_sync_queue() RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    IF NEW.status = 'QUEUED' AND NEW.sqlq_job_id IS NOT NULL AND (NEW.run_after IS NULL OR NEW.run_after <= now()) THEN
+        PERFORM pg_notify('mergestat_repo_sync_queue', NEW.id::TEXT);
+    END IF;
+    RETURN NULL;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS repo_sync_queue_notify_trigger ON mergestat.repo_sync_queue;
+CREATE TRIGGER repo_sync_queue_notify_trigger AFTER UPDATE OF sqlq_job_id ON mergestat.repo_sync_queue
+FOR EACH ROW WHEN (OLD.sqlq_job_id IS DISTINCT FROM NEW.sqlq_job_id) EXECUTE FUNCTION mergestat.notify_repo_sync_queue();
+
+COMMENT ON FUNCTION mergestat.notify_repo_sync_queue() IS 'Sends a notification on the mergestat_repo_sync_queue channel, with the ID of the job as payload, when the sqlq job of a queued repo sync job is created';
+
+-- the jobs queued before the upgrade are executed by sqlq as well, the ones no worker can run are marked as FAILED by
+-- the timeout routine
+UPDATE mergestat.repo_sync_queue rsq SET sqlq_job_id = mergestat.enqueue_repo_sync_job(rsq)
+FROM mergestat.repo_syncs rs
+WHERE rsq.status = 'QUEUED' AND rsq.sqlq_job_id IS NULL AND rs.id = rsq.repo_sync_id AND mergestat.sync_type_runnable(rs.sync_type);
+
+-- a queued job that's canceled is canceled in sqlq as well, so that no worker picks it up
+CREATE OR REPLACE FUNCTION mergestat.cancel_repo_sync_job(repo_sync_queue_id BIGINT)
+RETURNS BOOLEAN
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    _status TEXT;
+    _sqlq_job_id UUID;
+BEGIN
+    SELECT status, sqlq_job_id INTO _status, _sqlq_job_id FROM mergestat.repo_sync_queue WHERE id = repo_sync_queue_id FOR UPDATE;
+
+    IF _status = 'QUEUED' THEN
+        UPDATE mergestat.repo_sync_queue SET status = 'CANCELED', cancel_requested_at = now() WHERE id = repo_sync_queue_id;
+        UPDATE sqlq.jobs SET status = 'cancelled', completed_at = now() WHERE id = _sqlq_job_id AND status = 'pending';
+        INSERT INTO mergestat.repo_sync_logs (repo_sync_queue_id, log_type, message) VALUES (repo_sync_queue_id, 'WARNING', 'sync was canceled before it started');
+        RETURN TRUE;
+    ELSEIF _status = 'RUNNING' THEN
+        UPDATE mergestat.repo_sync_queue SET cancel_requested_at = now() WHERE id = repo_sync_queue_id AND cancel_requested_at IS NULL;
+        RETURN TRUE;
+    END IF;
+
+    RETURN FALSE;
+END;
+$$;
+
+COMMENT ON COLUMN mergestat.repo_sync_queue.run_after IS 'timestamp before which the job is not dequeued, e.g. to spread scheduled syncs with the jitter of their sync type';
+
+-- jobs are run by the sqlq worker of the workers, not by exec loops. Each running job of a worker is shown in one
+-- of its slots, up to its concurrency.
+CREATE OR REPLACE VIEW mergestat.worker_exec_loops AS
+SELECT
+    w.id AS worker_id,
+    w.hostname,
+    w.last_heartbeat_at,
+    exec_loop.n AS exec_loop,
+    rsq.id AS repo_sync_queue_id,
+    rsq.sync_type,
+    rsq.repo_id,
+    rsq.started_at
+FROM mergestat.workers w
+CROSS JOIN LATERAL generate_series(0, w.concurrency - 1) AS exec_loop(n)
+LEFT JOIN (
+    SELECT rsq.id, rsq.worker_id, rsq.started_at, rs.sync_type, rs.repo_id,
+        row_number() OVER (PARTITION BY rsq.worker_id ORDER BY rsq.started_at, rsq.id) - 1 AS slot
+    FROM mergestat.repo_sync_queue rsq
+    INNER JOIN mergestat.repo_syncs rs ON rs.id = rsq.repo_sync_id
+    WHERE rsq.status = 'RUNNING'
+) rsq ON rsq.worker_id = w.id AND rsq.slot = exec_loop.n;
+
+ALTER TABLE mergestat.repo_sync_queue DROP COLUMN IF EXISTS worker_exec_loop;
+
+COMMENT ON COLUMN mergestat.workers.concurrency IS 'number of jobs the worker runs concurrently';
+
+COMMIT;
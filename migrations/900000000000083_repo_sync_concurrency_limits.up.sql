@@ -0,0 +1,95 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS mergestat.repo_sync_concurrency_limits (
+    provider UUID NOT NULL REFERENCES mergestat.providers(id) ON UPDATE RESTRICT ON DELETE CASCADE,
+    type_group TEXT NOT NULL REFERENCES mergestat.repo_sync_type_groups("group") ON UPDATE RESTRICT ON DELETE CASCADE,
+    concurrent_syncs INTEGER NOT NULL,
+    CONSTRAINT repo_sync_concurrency_limits_pkey PRIMARY KEY (provider, type_group),
+    CONSTRAINT repo_sync_concurrency_limits_concurrent_syncs_check CHECK (concurrent_syncs > 0)
+);
+
+COMMENT ON TABLE mergestat.repo_sync_concurrency_limits IS 'Maximum number of syncs of a type group running at once, across all workers, for the repos of a provider. Without a limit, the concurrent syncs of the type group apply';
+COMMENT ON COLUMN mergestat.repo_sync_concurrency_limits.provider IS 'provider of the repos the limit applies to';
+COMMENT ON COLUMN mergestat.repo_sync_concurrency_limits.type_group IS 'type group of the syncs the limit applies to';
+COMMENT ON COLUMN mergestat.repo_sync_concurrency_limits.concurrent_syncs IS 'maximum number of syncs running at once';
+
+-- sync_queue_name returns the name of the sqlq queue of the syncs of a type group for the repos of a provider
+CREATE OR REPLACE FUNCTION mergestat.sync_queue_name(type_group TEXT, provider UUID) RETURNS TEXT
+LANGUAGE SQL IMMUTABLE
+AS $$
+    SELECT 'syncs-' || lower(type_group) || '-' || COALESCE(provider::TEXT, 'default');
+$$;
+
+-- sync_concurrency returns the maximum number of syncs of a type group running at once for the repos of a provider
+CREATE OR REPLACE FUNCTION mergestat.sync_concurrency(type_group TEXT, provider UUID) RETURNS INTEGER
+LANGUAGE SQL STABLE
+AS $$
+    SELECT COALESCE(
+        (SELECT l.concurrent_syncs FROM mergestat.repo_sync_concurrency_limits l WHERE l.provider = $2 AND l.type_group = $1),
+        (SELECT g.concurrent_syncs FROM mergestat.repo_sync_type_groups g WHERE g.group = $1)
+    );
+$$;
+
+CREATE OR REPLACE FUNCTION mergestat.enqueue_repo_sync_job(job mergestat.repo_sync_queue) RETURNS UUID
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    _queue TEXT;
+    _concurrency INTEGER;
+    _sync_type TEXT;
+    _sqlq_job_id UUID;
+BEGIN
+    SELECT mergestat.sync_queue_name(job.type_group, r.provider), mergestat.sync_concurrency(job.type_group, r.provider), rs.sync_type
+    INTO _queue, _concurrency, _sync_type
+    FROM mergestat.repo_syncs rs
+    INNER JOIN public.repos r ON r.id = rs.repo_id
+    WHERE rs.id = job.repo_sync_id;
+
+    IF NOT mergestat.sync_type_runnable(_sync_type) THEN
+        UPDATE mergestat.repo_sync_queue SET status = 'FAILED', done_at = now() WHERE id = job.id;
+        INSERT INTO mergestat.repo_sync_logs (repo_sync_queue_id, log_type, message)
+        VALUES (job.id, 'ERROR', 'no worker can run syncs of type ' || _sync_type || ', see the sync types of the workers in mergestat.workers');
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO sqlq.queues (name, concurrency, priority)
+    VALUES (_queue, _concurrency, CASE WHEN job.type_group = 'GITHUB' THEN 1 ELSE 2 END)
+    ON CONFLICT (name) DO UPDATE SET concurrency = excluded.concurrency, priority = excluded.priority
+    WHERE (queues.concurrency, queues.priority) IS DISTINCT FROM (excluded.concurrency, excluded.priority);
+
+    -- run_after of sqlq jobs is a delay in nanoseconds
+    INSERT INTO sqlq.jobs (queue, typename, parameters, priority, max_retries, run_after)
+    VALUES (_queue, 'repos/sync/' || _sync_type, json_build_object('id', job.id), job.priority, 1,
+        (GREATEST(EXTRACT(EPOCH FROM job.run_after - now()), 0) * 1e9)::BIGINT)
+    RETURNING id INTO _sqlq_job_id;
+
+    RETURN _sqlq_job_id;
+END;
+$$;
+
+-- the concurrency of the queues is enforced by sqlq when dequeuing jobs, so changes to the limits (or to the concurrent
+-- syncs of the type groups) are applied to the existing queues right away
+CREATE OR REPLACE FUNCTION mergestat.update_sync_queues_concurrency() RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    UPDATE sqlq.queues q SET concurrency = mergestat.sync_concurrency(g.group, p.id)
+    FROM mergestat.repo_sync_type_groups g
+    CROSS JOIN (SELECT id FROM mergestat.providers UNION ALL SELECT NULL) p
+    WHERE q.name = mergestat.sync_queue_name(g.group, p.id)
+        AND q.concurrency IS DISTINCT FROM mergestat.sync_concurrency(g.group, p.id);
+    RETURN NULL;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS repo_sync_concurrency_limits_update_queues_trigger ON mergestat.repo_sync_concurrency_limits;
+CREATE TRIGGER repo_sync_concurrency_limits_update_queues_trigger AFTER INSERT OR UPDATE OR DELETE ON mergestat.repo_sync_concurrency_limits
+FOR EACH STATEMENT EXECUTE FUNCTION mergestat.update_sync_queues_concurrency();
+
+DROP TRIGGER IF EXISTS repo_sync_type_groups_update_queues_trigger ON mergestat.repo_sync_type_groups;
+CREATE TRIGGER repo_sync_type_groups_update_queues_trigger AFTER UPDATE OF concurrent_syncs ON mergestat.repo_sync_type_groups
+FOR EACH STATEMENT EXECUTE FUNCTION mergestat.update_sync_queues_concurrency();
+
+COMMENT ON FUNCTION mergestat.update_sync_queues_concurrency() IS 'Applies the concurrency limits of the syncs to their sqlq queues';
+
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+
+-- GIT_FILES syncs can be configured with a size above which the contents of files aren't stored
+UPDATE mergestat.repo_sync_types SET settings_schema = '{
+    "type": "object",
+    "properties": {
+        "refs": {
+            "description": "names or globs of the branches and tags to sync, defaults to the ref configured on the repo or its default branch",
+            "type": "array",
+            "items": { "type": "string", "minLength": 1 }
+        },
+        "maxFileSize": {
+            "description": "size in bytes above which the contents of files are not stored, their path and metadata are still recorded",
+            "type": "integer",
+            "minimum": 1
+        }
+    },
+    "additionalProperties": false
+}'::jsonb WHERE type = 'GIT_FILES';
+
+COMMIT;
@@ -0,0 +1,30 @@
+BEGIN;
+
+ALTER TABLE public.git_files
+    ADD COLUMN IF NOT EXISTS blob_sha TEXT,
+    ADD COLUMN IF NOT EXISTS size BIGINT,
+    ADD COLUMN IF NOT EXISTS language TEXT,
+    ADD COLUMN IF NOT EXISTS is_binary BOOLEAN,
+    ADD COLUMN IF NOT EXISTS is_vendored BOOLEAN,
+    ADD COLUMN IF NOT EXISTS is_generated BOOLEAN,
+    ADD COLUMN IF NOT EXISTS is_documentation BOOLEAN,
+    ADD COLUMN IF NOT EXISTS code_lines INTEGER,
+    ADD COLUMN IF NOT EXISTS comment_lines INTEGER,
+    ADD COLUMN IF NOT EXISTS blank_lines INTEGER,
+    ADD COLUMN IF NOT EXISTS contents_omitted_reason TEXT;
+
+COMMENT ON COLUMN public.git_files.blob_sha IS 'hash of the git blob of the file';
+COMMENT ON COLUMN public.git_files.size IS 'size of the file in bytes';
+COMMENT ON COLUMN public.git_files.language IS 'language of the file, as detected by go-enry, NULL if unknown';
+COMMENT ON COLUMN public.git_files.is_binary IS 'boolean to determine if the file is binary, NULL if its contents were omitted for being too large';
+COMMENT ON COLUMN public.git_files.is_vendored IS 'boolean to determine if the file is vendored, e.g. a third party dependency';
+COMMENT ON COLUMN public.git_files.is_generated IS 'boolean to determine if the file is generated, NULL if its contents were omitted for being too large';
+COMMENT ON COLUMN public.git_files.is_documentation IS 'boolean to determine if the file is documentation';
+COMMENT ON COLUMN public.git_files.code_lines IS 'number of lines of code of the file, NULL if it is binary or its contents were omitted for being too large';
+COMMENT ON COLUMN public.git_files.comment_lines IS 'number of comment lines of the file, NULL if it is binary or its contents were omitted for being too large';
+COMMENT ON COLUMN public.git_files.blank_lines IS 'number of blank lines of the file, NULL if it is binary or its contents were omitted for being too large';
+COMMENT ON COLUMN public.git_files.contents_omitted_reason IS 'why the contents of the file were not stored, if they were not: invalid_utf8 (the file is not valid UTF-8 text) or too_large (the file is larger than the maxFileSize setting of the sync)';
+
+CREATE INDEX IF NOT EXISTS idx_git_files_repo_id_language ON public.git_files (repo_id, language);
+
+COMMIT;
@@ -0,0 +1,81 @@
+BEGIN;
+
+-- the contents of the files are stored once per blob, in git_blobs, and the files reference them by the hash of the
+-- blob, so that repos sharing files (e.g. forks, or vendored trees) don't store the same contents over and over
+CREATE TABLE IF NOT EXISTS public.git_blobs (
+    sha TEXT NOT NULL,
+    contents TEXT,
+    size BIGINT NOT NULL,
+    _mergestat_synced_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    CONSTRAINT git_blobs_pkey PRIMARY KEY (sha)
+);
+
+COMMENT ON TABLE public.git_blobs IS 'contents of the git blobs of the files of the repos, shared by all the files with the same contents';
+COMMENT ON COLUMN public.git_blobs.sha IS 'hash of the git blob';
+COMMENT ON COLUMN public.git_blobs.contents IS 'contents of the blob, NULL if they were omitted for every file referencing it (see git_files.contents_omitted_reason)';
+COMMENT ON COLUMN public.git_blobs.size IS 'size of the blob in bytes';
+COMMENT ON COLUMN public.git_blobs._mergestat_synced_at IS 'timestamp when record was synced into the MergeStat database';
+
+-- the files synced since their blob hash is recorded keep their contents, in the blobs they reference
+INSERT INTO public.git_blobs (sha, contents, size)
+SELECT DISTINCT ON (blob_sha) blob_sha, contents, COALESCE(size, octet_length(contents), 0)
+FROM public.git_files
+WHERE blob_sha IS NOT NULL
+ORDER BY blob_sha, contents IS NULL
+ON CONFLICT (sha) DO NOTHING;
+
+-- the files are stored in git_file_entries, and git_files is a view of them with the contents of their blob, so that
+-- existing queries keep working. The contents of the files synced before their blob hash was recorded are cleared,
+-- they're loaded again by the next GIT_FILES sync of their repo.
+ALTER TABLE public.git_files RENAME TO git_file_entries;
+ALTER TABLE public.git_file_entries DROP COLUMN IF EXISTS contents;
+
+ALTER TABLE public.git_file_entries DROP CONSTRAINT IF EXISTS git_files_blob_sha_fkey;
+ALTER TABLE public.git_file_entries ADD CONSTRAINT git_files_blob_sha_fkey FOREIGN KEY (blob_sha) REFERENCES public.git_blobs(sha) ON UPDATE RESTRICT ON DELETE RESTRICT;
+CREATE INDEX IF NOT EXISTS idx_git_files_blob_sha_fkey ON public.git_file_entries (blob_sha);
+
+COMMENT ON TABLE public.git_file_entries IS 'git files (paths and metadata) of a repo, their contents are in git_blobs (see the git_files view)';
+COMMENT ON COLUMN public.git_file_entries.blob_sha IS 'hash of the git blob of the file, foreign key for public.git_blobs.sha';
+
+CREATE OR REPLACE VIEW public.git_files AS
+SELECT
+    git_file_entries.repo_id,
+    git_file_entries.path,
+    git_file_entries.executable,
+    git_blobs.contents,
+    git_file_entries._mergestat_synced_at,
+    git_file_entries.ref,
+    git_file_entries.blob_sha,
+    git_file_entries.size,
+    git_file_entries.language,
+    git_file_entries.is_binary,
+    git_file_entries.is_vendored,
+    git_file_entries.is_generated,
+    git_file_entries.is_documentation,
+    git_file_entries.code_lines,
+    git_file_entries.comment_lines,
+    git_file_entries.blank_lines,
+    git_file_entries.contents_omitted_reason
+FROM public.git_file_entries
+LEFT JOIN public.git_blobs ON git_blobs.sha = git_file_entries.blob_sha;
+
+COMMENT ON VIEW public.git_files IS E'@primaryKey repo_id,ref,path\n@foreignKey (repo_id) references public.repos (id)\ngit files (content and paths) of a repo, the contents being those of the blob of the file';
+COMMENT ON COLUMN public.git_files.repo_id IS 'foreign key for public.repos.id';
+COMMENT ON COLUMN public.git_files.path IS 'path of the file';
+COMMENT ON COLUMN public.git_files.executable IS 'boolean to determine if the file is an executable';
+COMMENT ON COLUMN public.git_files.contents IS 'contents of the file';
+COMMENT ON COLUMN public.git_files._mergestat_synced_at IS 'timestamp when record was synced into the MergeStat database';
+COMMENT ON COLUMN public.git_files.ref IS 'ref the file was synced from';
+COMMENT ON COLUMN public.git_files.blob_sha IS 'hash of the git blob of the file, foreign key for public.git_blobs.sha';
+COMMENT ON COLUMN public.git_files.size IS 'size of the file in bytes';
+COMMENT ON COLUMN public.git_files.language IS 'language of the file, as detected by go-enry, NULL if unknown';
+COMMENT ON COLUMN public.git_files.is_binary IS 'boolean to determine if the file is binary, NULL if its contents were omitted for being too large';
+COMMENT ON COLUMN public.git_files.is_vendored IS 'boolean to determine if the file is vendored, e.g. a third party dependency';
+COMMENT ON COLUMN public.git_files.is_generated IS 'boolean to determine if the file is generated, NULL if its contents were omitted for being too large';
+COMMENT ON COLUMN public.git_files.is_documentation IS 'boolean to determine if the file is documentation';
+COMMENT ON COLUMN public.git_files.code_lines IS 'number of lines of code of the file, NULL if it is binary or its contents were omitted for being too large';
+COMMENT ON COLUMN public.git_files.comment_lines IS 'number of comment lines of the file, NULL if it is binary or its contents were omitted for being too large';
+COMMENT ON COLUMN public.git_files.blank_lines IS 'number of blank lines of the file, NULL if it is binary or its contents were omitted for being too large';
+COMMENT ON COLUMN public.git_files.contents_omitted_reason IS 'why the contents of the file were not stored, if they were not: invalid_utf8 (the file is not valid UTF-8 text) or too_large (the file is larger than the maxFileSize setting of the sync)';
+
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+
+ALTER TABLE public.git_commits
+    ADD COLUMN IF NOT EXISTS parent_hashes TEXT[],
+    ADD COLUMN IF NOT EXISTS tree_hash TEXT,
+    ADD COLUMN IF NOT EXISTS signature_type TEXT,
+    ADD COLUMN IF NOT EXISTS signer_key_id TEXT;
+
+COMMENT ON COLUMN public.git_commits.parent_hashes IS 'hashes of the parents of the commit, in order (the first parent first)';
+COMMENT ON COLUMN public.git_commits.tree_hash IS 'hash of the tree of the commit';
+COMMENT ON COLUMN public.git_commits.signature_type IS 'type of the signature of the commit: gpg, ssh or x509, NULL if the commit is not signed';
+COMMENT ON COLUMN public.git_commits.signer_key_id IS 'ID of the key that signed the commit, as shown by git log --format=%GK: the long key ID for gpg, the SHA256 fingerprint of the public key for ssh, NULL if unknown';
+
+-- finds the children of a commit
+CREATE INDEX IF NOT EXISTS idx_git_commits_parent_hashes ON public.git_commits USING gin(parent_hashes);
+
+-- the commits synced before are missing their parents, tree and signature, so the next GIT_COMMITS syncs reload all of them
+DELETE FROM mergestat.repo_sync_checkpoints
+WHERE repo_sync_id IN (SELECT id FROM mergestat.repo_syncs WHERE sync_type = 'GIT_COMMITS');
+
+COMMIT;
@@ -0,0 +1,41 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS public.git_commit_trailers (
+    repo_id UUID NOT NULL,
+    commit_hash TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    name TEXT,
+    email TEXT,
+    _mergestat_synced_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    CONSTRAINT git_commit_trailers_pkey PRIMARY KEY (repo_id, commit_hash, position),
+    CONSTRAINT git_commit_trailers_commit_fkey FOREIGN KEY (repo_id, commit_hash) REFERENCES public.git_commits(repo_id, hash) ON UPDATE RESTRICT ON DELETE CASCADE
+);
+
+COMMENT ON TABLE public.git_commit_trailers IS 'trailers of the messages of the commits of a repo, such as Co-authored-by or Signed-off-by';
+COMMENT ON COLUMN public.git_commit_trailers.repo_id IS 'foreign key for public.repos.id';
+COMMENT ON COLUMN public.git_commit_trailers.commit_hash IS 'hash of the commit';
+COMMENT ON COLUMN public.git_commit_trailers.position IS 'position of the trailer in the message of the commit, starting at 0';
+COMMENT ON COLUMN public.git_commit_trailers.key IS 'key of the trailer, lower cased (e.g. co-authored-by, signed-off-by, reviewed-by)';
+COMMENT ON COLUMN public.git_commit_trailers.value IS 'value of the trailer';
+COMMENT ON COLUMN public.git_commit_trailers.name IS 'name of the identity the value of the trailer refers to, if it is one (e.g. Jane Doe <jane@example.com>)';
+COMMENT ON COLUMN public.git_commit_trailers.email IS 'email of the identity the value of the trailer refers to, if it is one';
+COMMENT ON COLUMN public.git_commit_trailers._mergestat_synced_at IS 'timestamp when record was synced into the MergeStat database';
+
+CREATE INDEX IF NOT EXISTS idx_git_commit_trailers_repo_id_key ON public.git_commit_trailers (repo_id, key);
+
+ALTER TABLE public.git_commits
+    ADD COLUMN IF NOT EXISTS conventional_type TEXT,
+    ADD COLUMN IF NOT EXISTS conventional_scope TEXT,
+    ADD COLUMN IF NOT EXISTS is_breaking_change BOOLEAN;
+
+COMMENT ON COLUMN public.git_commits.conventional_type IS 'type of the commit, lower cased (e.g. feat or fix), if its message follows the Conventional Commits specification';
+COMMENT ON COLUMN public.git_commits.conventional_scope IS 'scope of the commit, if its message follows the Conventional Commits specification and has one';
+COMMENT ON COLUMN public.git_commits.is_breaking_change IS 'boolean to determine if the commit introduces a breaking change (a ! after its type or a BREAKING CHANGE trailer), NULL if its message does not follow the Conventional Commits specification';
+
+-- the commits synced before have no trailers nor classification, so the next GIT_COMMITS syncs reload all of them
+DELETE FROM mergestat.repo_sync_checkpoints
+WHERE repo_sync_id IN (SELECT id FROM mergestat.repo_syncs WHERE sync_type = 'GIT_COMMITS');
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE mergestat.repo_sync_checkpoints ADD COLUMN IF NOT EXISTS settings_hash TEXT;
+
+COMMENT ON COLUMN mergestat.repo_sync_checkpoints.settings_hash IS 'hash of the settings of the sync that affect its results, when the checkpoint was last updated. A sync processes everything again if they changed since then';
+
+ALTER TABLE mergestat.repo_sync_checkpoints ADD COLUMN IF NOT EXISTS failed_paths TEXT[] NOT NULL DEFAULT '{}';
+
+COMMENT ON COLUMN mergestat.repo_sync_checkpoints.failed_paths IS 'paths of the files that could not be processed by the sync when the checkpoint was last updated, which the next sync processes again even if they did not change';
+
+COMMIT;
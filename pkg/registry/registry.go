@@ -0,0 +1,202 @@
+// Package registry provides the registry of sync handlers executed by the worker.
+//
+// Every sync type (a row in mergestat.repo_sync_types) is executed by a SyncHandler registered under
+// the same name. The built-in sync types register themselves when the worker starts. Third-party
+// packages can provide handlers for their own sync types by calling Register from an init function,
+// and having the worker binary import them (with a blank import) at build time:
+//
+//	func init() {
+//		registry.Register("MY_SYNC", registry.HandlerFunc(handle), registry.CapabilityClone)
+//	}
+//
+// The sync type must also be added to mergestat.repo_sync_types for syncs of it to be scheduled.
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/rs/zerolog"
+)
+
+// Capability is something a handler requires from the worker in order to run.
+type Capability string
+
+const (
+	// CapabilityClone indicates that the handler works on a local clone of the repo (see Runtime.Checkout)
+	CapabilityClone Capability = "clone"
+
+	// CapabilityGitHubToken indicates that the handler requires a GitHub token to be configured for the repo's provider.
+	// The worker fails the sync (without invoking the handler) if there's none.
+	CapabilityGitHubToken Capability = "github-token"
+)
+
+// Executable returns the capability of running the named executable (e.g. trivy), which must be in the worker's PATH.
+func Executable(name string) Capability { return Capability(executablePrefix + name) }
+
+const executablePrefix = "executable:"
+
+// Executable returns the name of the executable, if the capability is the one of running an executable
+func (c Capability) Executable() (string, bool) {
+	if strings.HasPrefix(string(c), executablePrefix) {
+		return strings.TrimPrefix(string(c), executablePrefix), true
+	}
+	return "", false
+}
+
+// LogType is the type of message logged to a sync's logs
+type LogType string
+
+const (
+	LogTypeInfo  LogType = "INFO"
+	LogTypeWarn  LogType = "WARNING"
+	LogTypeError LogType = "ERROR"
+)
+
+// Job is a sync dequeued from mergestat.repo_sync_queue
+type Job struct {
+	ID       int64           // ID of the job in mergestat.repo_sync_queue
+	SyncID   uuid.UUID       // ID of the sync in mergestat.repo_syncs
+	SyncType string          // type of the sync, the name the handler is registered under
+	RepoID   uuid.UUID       // ID of the repo in public.repos
+	Repo     string          // URL of the repo
+	Ref      string          // ref configured on the repo, if any
+	Attempt  int             // number of the current attempt of the job, starting at 1
+	Settings json.RawMessage // settings of the sync, already validated against the settings_schema of its type
+}
+
+// DecodeSettings decodes the settings of the job into v, typically a struct declaring the settings of the
+// sync type. v is left untouched if the sync has no settings.
+func (j *Job) DecodeSettings(v interface{}) error {
+	if len(j.Settings) == 0 {
+		return nil
+	}
+	return json.Unmarshal(j.Settings, v)
+}
+
+// Runtime is the environment provided by the worker to a handler while it's processing a job.
+type Runtime interface {
+	// Logger returns a logger for the job
+	Logger() *zerolog.Logger
+
+	// Pool returns the pool of connections to the database handlers write their results to
+	Pool() *pgxpool.Pool
+
+	// Log appends a message to the logs of the sync, visible to users
+	Log(ctx context.Context, typ LogType, message string) error
+
+	// Checkout returns the path of an up-to-date clone of the repo, private to the job, with its working tree
+	// checked out. Release must be called once the handler is done with it, which removes the clone.
+	Checkout(ctx context.Context) (path string, release func(), err error)
+
+	// Credentials returns the username and token of the provider the repo belongs to
+	Credentials(ctx context.Context) (username, token string, err error)
+
+	// Done marks the job as completed as part of the given transaction, which should be
+	// the one the handler writes its results in, so that both are committed together.
+	Done(ctx context.Context, tx pgx.Tx) error
+}
+
+// SyncHandler implements the logic of a sync type.
+type SyncHandler interface {
+	// Handle processes the given job, returning nil if it was successful.
+	// If an error is returned, it's logged to the sync's logs and the job is either retried (see Retryable)
+	// or marked as FAILED.
+	Handle(ctx context.Context, rt Runtime, job *Job) error
+}
+
+// retryable is an error marked as retryable by a handler
+type retryable struct{ error }
+
+func (r retryable) Unwrap() error { return r.error }
+
+// Retryable marks the error as transient. The job is attempted again (with a backoff) if it has attempts left,
+// regardless of the classes of errors retried for its sync type (see mergestat.repo_sync_types.retry_on).
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return retryable{err}
+}
+
+// IsRetryable reports whether the error, or any error it wraps, was marked as retryable
+func IsRetryable(err error) bool {
+	return errors.As(err, new(retryable))
+}
+
+// HandlerFunc is an adapter to use ordinary functions as SyncHandler.
+type HandlerFunc func(ctx context.Context, rt Runtime, job *Job) error
+
+func (fn HandlerFunc) Handle(ctx context.Context, rt Runtime, job *Job) error {
+	return fn(ctx, rt, job)
+}
+
+// Registration is a handler registered for a sync type
+type Registration struct {
+	Name         string
+	Handler      SyncHandler
+	Capabilities []Capability
+}
+
+// Requires reports whether the registered handler requires the given capability
+func (r Registration) Requires(c Capability) bool {
+	for _, capability := range r.Capabilities {
+		if capability == c {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	mu            sync.RWMutex
+	registrations = make(map[string]Registration)
+)
+
+// Register makes a handler available for the sync type with the given name.
+// It panics if a handler is already registered under the name, or if the handler is nil.
+func Register(name string, handler SyncHandler, capabilities ...Capability) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if handler == nil {
+		panic("registry: Register handler is nil")
+	}
+
+	if _, dup := registrations[name]; dup {
+		panic(fmt.Sprintf("registry: Register called twice for sync type: %s", name))
+	}
+
+	registrations[name] = Registration{Name: name, Handler: handler, Capabilities: capabilities}
+}
+
+// Lookup returns the handler registered for the sync type with the given name.
+func Lookup(name string) (Registration, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var r, ok = registrations[name]
+	return r, ok
+}
+
+// Names returns the sorted names of all sync types with a registered handler.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var names = make([]string, 0, len(registrations))
+	for name := range registrations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -131,8 +131,10 @@ export function mapToRepoSyncStateT(status: string): RepoSyncStateT {
     case 'QUEUED':
       return SYNC_STATUS.queued
     case 'WARNING':
+    case 'CANCELED':
       return SYNC_STATUS.warning
     case 'ERROR':
+    case 'FAILED':
       return SYNC_STATUS.error
     case 'DISABLED':
       return SYNC_STATUS.disabled